@@ -3,7 +3,10 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
 	"slices"
+	"strings"
+	"time"
 
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/dustin/go-humanize"
@@ -28,16 +31,28 @@ func New(version string) CLI {
 //nolint:gocognit // Lengthy command setup.
 func (c CLI) Execute() error {
 	var (
-		options    dirstat.Options
-		minSizeStr string
-		completion string
+		options      dirstat.Options
+		minSizeStr   string
+		maxSizeStr   string
+		completion   string
+		apparentSize bool
+		olderThan    string
+		newerThan    string
+		categories   []string
+		formatFile   string
 	)
 
-	defaultExcludes := []string{`.*\.git/.*`, `.*node_modules/.*`}
+	defaultExcludes := []string{"**/.git/", "**/node_modules/"}
 
 	defaultTopN := 10
 
-	allowedOutputs := []string{"table", "json"}
+	allowedOutputs := []string{"table", "json", "ndjson", "csv", "tsv", "markdown", "html"}
+
+	allowedRecords := []string{RecordsFiles, RecordsExtensions, RecordsDirs}
+
+	allowedTimeFields := []string{dirstat.TimeFieldMtime, dirstat.TimeFieldAtime, dirstat.TimeFieldCtime}
+
+	allowedGroupBy := []string{dirstat.GroupByExtension, dirstat.GroupByOwner, dirstat.GroupByCategory}
 
 	root := &cobra.Command{
 		Use:   "dirstat [flags] [path]",
@@ -79,10 +94,60 @@ func (c CLI) Execute() error {
 				return fmt.Errorf("invalid output format %q: must be one of %v", options.Output, allowedOutputs)
 			}
 
+			if formatFile != "" {
+				data, err := os.ReadFile(formatFile)
+				if err != nil {
+					return fmt.Errorf("reading format file: %w", err)
+				}
+
+				options.Format = string(data)
+			}
+
+			if options.Format != "" {
+				if _, err := ParseTemplate(options.Format); err != nil {
+					return err
+				}
+			}
+
+			delimited := options.Format == "" && (options.Output == "csv" || options.Output == "tsv")
+
+			if options.Records != "" && !delimited {
+				return errors.New("records require --output csv or tsv")
+			}
+
+			if delimited {
+				if options.Records == "" {
+					options.Records = RecordsFiles
+					if options.DirsMode {
+						options.Records = RecordsDirs
+					}
+				}
+
+				if !slices.Contains(allowedRecords, options.Records) {
+					return fmt.Errorf("invalid records %q: must be one of %v", options.Records, allowedRecords)
+				}
+
+				if options.Records == RecordsDirs && !options.DirsMode {
+					return errors.New("records \"dirs\" require --dirs")
+				}
+
+				if options.Records != RecordsDirs && options.DirsMode {
+					return fmt.Errorf("records %q are not available with --dirs", options.Records)
+				}
+			}
+
 			if options.Depth < 0 {
 				return errors.New("depth cannot be negative")
 			}
 
+			if options.GroupDepth < 0 {
+				return errors.New("group-depth cannot be negative")
+			}
+
+			if options.GroupDepth > 0 && !options.DirsMode {
+				return errors.New("group-depth requires --dirs")
+			}
+
 			if len(args) == 0 {
 				options.Path = "."
 			} else {
@@ -99,6 +164,111 @@ func (c CLI) Execute() error {
 				options.MinSize = int64(size) //nolint:gosec // Size conversion from humanize is safe
 			}
 
+			if maxSizeStr != "" {
+				size, err := humanize.ParseBytes(maxSizeStr)
+				if err != nil {
+					return fmt.Errorf("invalid max-size: %w", err)
+				}
+
+				options.MaxSize = int64(size) //nolint:gosec // Size conversion from humanize is safe
+			}
+
+			if options.MaxSize > 0 && options.MaxSize < options.MinSize {
+				return errors.New("max-size cannot be smaller than min-size")
+			}
+
+			if !slices.Contains(allowedTimeFields, options.TimeField) {
+				return fmt.Errorf("invalid time field %q: must be one of %v", options.TimeField, allowedTimeFields)
+			}
+
+			if options.Where != "" {
+				if _, err := dirstat.ParseWhere(options.Where, time.Now()); err != nil {
+					return fmt.Errorf("invalid where expression: %w", err)
+				}
+			}
+
+			for _, compound := range options.CompoundExtensions {
+				if !strings.HasPrefix(compound, ".") {
+					return fmt.Errorf("invalid compound extension %q: must start with '.'", compound)
+				}
+			}
+
+			for from, to := range options.ExtAliases {
+				if !strings.HasPrefix(from, ".") || !strings.HasPrefix(to, ".") {
+					return fmt.Errorf("invalid extension alias %q=%q: extensions must start with '.'", from, to)
+				}
+			}
+
+			options.Categories = make(map[string][]string, len(categories))
+
+			for _, category := range categories {
+				name, entries, found := strings.Cut(category, "=")
+				if !found || name == "" || entries == "" {
+					return fmt.Errorf("invalid category %q: must be name=.ext,glob,...", category)
+				}
+
+				options.Categories[name] = append(options.Categories[name], strings.Split(entries, ",")...)
+			}
+
+			if options.Output == "html" {
+				options.Tree = true
+			}
+
+			if options.Collapse < 0 {
+				return errors.New("collapse cannot be negative")
+			}
+
+			if options.MaxErrors <= 0 {
+				return errors.New("max-errors must be positive")
+			}
+
+			if options.MaxEmpties < 0 {
+				return errors.New("max-empties cannot be negative")
+			}
+
+			if options.Print0 && !options.Empties {
+				return errors.New("print0 requires --empties")
+			}
+
+			if options.Print0 && (options.Output != "table" || options.Format != "") {
+				return errors.New("print0 cannot be combined with --output, --format or --format-file")
+			}
+
+			// Cleanup scripts need the complete list, unless a cap is explicitly requested
+			if options.Print0 && !cmd.Flags().Lookup("max-empties").Changed {
+				options.MaxEmpties = 0
+			}
+
+			if !slices.Contains(allowedGroupBy, options.GroupBy) {
+				return fmt.Errorf("invalid breakdown %q: must be one of %v", options.GroupBy, allowedGroupBy)
+			}
+
+			if olderThan != "" {
+				age, err := dirstat.ParseDuration(olderThan)
+				if err != nil {
+					return fmt.Errorf("invalid older-than: %w", err)
+				}
+
+				if age < 0 {
+					return errors.New("older-than cannot be negative")
+				}
+
+				options.OlderThan = age
+			}
+
+			if newerThan != "" {
+				age, err := dirstat.ParseDuration(newerThan)
+				if err != nil {
+					return fmt.Errorf("invalid newer-than: %w", err)
+				}
+
+				if age < 0 {
+					return errors.New("newer-than cannot be negative")
+				}
+
+				options.NewerThan = age
+			}
+
 			// Clear default excludes if using dirs mode and exclude flag wasn't changed
 			if !cmd.Flags().Lookup("exclude").Changed && options.DirsMode {
 				options.Excludes = []string{}
@@ -113,14 +283,88 @@ func (c CLI) Execute() error {
 		"ext",
 		"x",
 		[]string{},
-		"File suffixes to include (e.g., .go,.md). Use '!' prefix to exclude (e.g., !.log,!_test.go)",
+		"Extensions (e.g., .go,.tar.gz) or other path suffixes (e.g., _test.go,.pb.go) to include. "+
+			"Use '!' prefix to exclude (e.g., !.log,!_test.go)",
 	)
+	root.Flags().BoolVar(&options.NoHidden, "no-hidden", false,
+		"Skip hidden files and directories (names starting with '.')")
+	root.Flags().BoolVar(&options.HiddenOnly, "hidden-only", false,
+		"Analyze only hidden files and the contents of hidden directories")
+	root.Flags().StringSliceVar(&options.CompoundExtensions, "compound-ext", dirstat.DefaultCompoundExtensions,
+		"Multi-part extensions treated as a single extension")
+	root.Flags().BoolVar(&options.FoldCase, "fold-case", false,
+		"Compare and group extensions case-insensitively (.JPG = .jpg)")
+	root.Flags().StringToStringVar(&options.ExtAliases, "ext-alias", map[string]string{},
+		"Group extensions under another one (e.g., .jpeg=.jpg,.htm=.html)")
 	root.Flags().StringVar(&minSizeStr, "min-size", "0KB", "Minimum file size (e.g., 1KB)")
+	root.Flags().StringVar(&maxSizeStr, "max-size", "", "Maximum file size (e.g., 1GB, empty=unlimited)")
+	root.Flags().StringVar(&olderThan, "older-than", "",
+		"Only include files older than this age (e.g., 90d, 2w, 6mo, 1y, 12h)")
+	root.Flags().StringVar(&newerThan, "newer-than", "",
+		"Only include files newer than this age (e.g., 90d, 2w, 6mo, 1y, 12h)")
+	root.Flags().StringVar(&options.TimeField, "time", dirstat.TimeFieldMtime,
+		"Timestamp for age filters and buckets: mtime, atime or ctime")
+	root.Flags().StringVar(&options.Where, "where", "",
+		"Filter expression files must satisfy "+
+			`(e.g., 'size > 50MB && (ext == ".log" || name =~ "^core") && mtime < -7d')`)
+	root.Flags().StringSliceVar(&options.Owners, "owner", []string{},
+		"Only include files owned by these users (names or IDs)")
+	root.Flags().StringSliceVar(&options.Groups, "group", []string{},
+		"Only include files belonging to these groups (names or IDs)")
+	root.Flags().BoolVar(&options.Sniff, "sniff", false,
+		"Classify files by content (ELF, PE, gzip, zstd, zip, PNG, JPEG, PDF, SQLite, text)")
+	root.Flags().BoolVar(&options.Mismatches, "mismatches", false,
+		"List files whose content disagrees with their extension (implies --sniff)")
+	root.Flags().BoolVar(&options.Empties, "empties", false,
+		"List zero-byte files and directories without any analyzed entries")
+	root.Flags().IntVar(&options.MaxEmpties, "max-empties", dirstat.DefaultMaxEmpties,
+		"Maximum number of empty files and directories listed each (0=unlimited, default unlimited with --print0)")
+	root.Flags().BoolVarP(&options.Print0, "print0", "0", false,
+		"Print only the empty paths, NUL-terminated, with files first and the deepest directories first "+
+			"(requires --empties)")
+	root.Flags().StringVar(&options.GroupBy, "by", dirstat.GroupByExtension,
+		"Breakdown shown in the summary: extension, owner or category")
+	root.Flags().StringArrayVar(&categories, "category", []string{},
+		"Add or override a category with extensions and name globs (e.g., 'media=.raw,.cr2' or 'build=*.min.js')")
 	root.Flags().IntVarP(&options.TopN, "top", "t", defaultTopN, "Number of top files to display")
-	root.Flags().StringVarP(&options.Output, "output", "o", "table", "Output format: json or table")
-	root.Flags().StringSliceVarP(&options.Excludes, "exclude", "e", defaultExcludes, "Regex patterns to exclude")
+	root.Flags().StringVarP(&options.Output, "output", "o", "table",
+		"Output format: table, json, ndjson, csv, tsv, markdown or html")
+	root.Flags().StringVar(&options.Format, "format", "",
+		"Go template executed against the statistics instead of --output "+
+			"(e.g., '{{range .TopFiles}}{{.Path}}{{\"\\n\"}}{{end}}')")
+	root.Flags().StringVar(&formatFile, "format-file", "", "File containing a Go template used like --format")
+	root.Flags().StringVar(&options.Records, "records", "",
+		"Record set for csv and tsv output: files, extensions or dirs (default: files, or dirs with --dirs)")
+	root.Flags().BoolVar(&options.Tree, "tree", false,
+		"Include the directory hierarchy with the largest files per directory in json output (implied by -o html)")
+	root.Flags().IntVar(&options.Collapse, "collapse", 0,
+		"Wrap markdown lists longer than this many rows in a collapsible details block (0=never)")
+	root.Flags().StringSliceVar(&options.Includes, "include", []string{},
+		"Glob patterns relative to the scan root that files must match (e.g., src/**/*.go). "+
+			"Use 're:' prefix for regexes")
+	root.Flags().StringSliceVarP(&options.Excludes, "exclude", "e", defaultExcludes,
+		"Glob patterns relative to the scan root to exclude, trailing '/' for directories. "+
+			"Use 're:' prefix for regexes")
+	root.Flags().BoolVar(&options.GitIgnore, "gitignore", false,
+		"Skip entries ignored by .gitignore, .ignore and git exclude files")
+	root.Flags().BoolVar(&options.IgnoredOnly, "ignored-only", false,
+		"Analyze only entries ignored by .gitignore, .ignore and git exclude files")
 	root.Flags().IntVarP(&options.Depth, "depth", "d", 0, "Maximum traversal depth (0=unlimited)")
 	root.Flags().BoolVar(&options.DirsMode, "dirs", false, "Analyze directories instead of individual files")
+	root.Flags().IntVar(&options.GroupDepth, "group-depth", 0,
+		"Roll up sizes into all ancestor directories up to this depth (requires --dirs, 0=direct parent only)")
+	root.Flags().BoolVar(&options.Follow, "follow", false,
+		"Follow symbolic links, skipping cycles and duplicate targets")
+	root.Flags().BoolVar(&options.OneFileSystem, "one-file-system", false, "Skip directories on other filesystems")
+	root.Flags().BoolVar(&apparentSize, "apparent-size", false,
+		"Use apparent file sizes for sorting and percentages (default)")
+	root.Flags().BoolVar(&options.DiskUsage, "disk-usage", false,
+		"Use allocated disk usage for sorting and percentages")
+	root.Flags().BoolVar(&options.CountLinks, "count-links", false,
+		"Count hard-linked files once per path instead of once per inode")
+	root.Flags().BoolVar(&options.Strict, "strict", false, "Exit with an error if any path could not be read")
+	root.Flags().IntVar(&options.MaxErrors, "max-errors", dirstat.DefaultMaxErrors,
+		"Maximum number of unreadable paths listed (all of them are counted)")
 	root.Flags().BoolVar(&options.Debug, "debug", false, "Enable debug output")
 	root.Flags().BoolVarP(&options.Integration, "init", "i", false, "Output init script for shell usage")
 	root.Flags().
@@ -129,6 +373,12 @@ func (c CLI) Execute() error {
 
 	_ = root.Flags().MarkHidden("shell-completion")
 
+	root.MarkFlagsMutuallyExclusive("apparent-size", "disk-usage")
+	root.MarkFlagsMutuallyExclusive("no-hidden", "hidden-only")
+	root.MarkFlagsMutuallyExclusive("format", "format-file")
+	root.MarkFlagsMutuallyExclusive("format", "output")
+	root.MarkFlagsMutuallyExclusive("format-file", "output")
+
 	root.Flags().SortFlags = false
 
 	return root.Execute() //nolint:wrapcheck // Error does not need additional wrapping.
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/idelchi/dirstat/internal/dirstat"
+)
+
+// Record sets selectable for delimited output.
+const (
+	RecordsFiles      = "files"
+	RecordsExtensions = "extensions"
+	RecordsDirs       = "dirs"
+)
+
+// PrintDelimited outputs one record set as comma- or tab-separated values with a header row.
+// Fields containing the separator, quotes or newlines are quoted.
+func PrintDelimited(stats *dirstat.Stats, writer io.Writer, comma rune, records string) error {
+	out := csv.NewWriter(writer)
+	out.Comma = comma
+
+	var rows [][]string
+
+	switch records {
+	case RecordsExtensions:
+		rows = extensionRecords(stats)
+	case RecordsFiles, RecordsDirs:
+		rows = pathRecords(stats)
+	default:
+		return fmt.Errorf("unknown records %q", records)
+	}
+
+	if err := out.WriteAll(rows); err != nil {
+		return fmt.Errorf("writing records: %w", err)
+	}
+
+	return nil
+}
+
+// pathRecords returns the largest files or directories, largest first.
+func pathRecords(stats *dirstat.Stats) [][]string {
+	rows := [][]string{{"rank", "path", "size", "disk_size", "percent"}}
+
+	for i := len(stats.TopFiles) - 1; i >= 0; i-- {
+		file := stats.TopFiles[i]
+
+		rows = append(rows, []string{
+			strconv.Itoa(len(stats.TopFiles) - i),
+			file.Path,
+			strconv.FormatInt(file.Size, 10),
+			strconv.FormatInt(file.DiskSize, 10),
+			percent(file.Bytes(stats.DiskUsage), stats.Total()),
+		})
+	}
+
+	return rows
+}
+
+// extensionRecords returns the statistics of all extensions, largest first.
+func extensionRecords(stats *dirstat.Stats) [][]string {
+	exts := make([]string, 0, len(stats.ExtStats))
+	for ext := range stats.ExtStats {
+		exts = append(exts, ext)
+	}
+
+	sort.Slice(exts, func(i, j int) bool {
+		bytesI, bytesJ := stats.ExtStats[exts[i]].Bytes(stats.DiskUsage), stats.ExtStats[exts[j]].Bytes(stats.DiskUsage)
+		if bytesI != bytesJ {
+			return bytesI > bytesJ
+		}
+
+		return exts[i] < exts[j]
+	})
+
+	rows := [][]string{{"rank", "extension", "count", "size", "disk_size", "percent"}}
+
+	for i, ext := range exts {
+		stat := stats.ExtStats[ext]
+
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			ext,
+			strconv.Itoa(stat.Count),
+			strconv.FormatInt(stat.Size, 10),
+			strconv.FormatInt(stat.DiskSize, 10),
+			percent(stat.Bytes(stats.DiskUsage), stats.Total()),
+		})
+	}
+
+	return rows
+}
+
+// percent formats part as a percentage of total with two decimals.
+func percent(part, total int64) string {
+	if total <= 0 {
+		return "0.00"
+	}
+
+	return strconv.FormatFloat(100.0*float64(part)/float64(total), 'f', 2, 64) //nolint:mnd // Percentage calculation
+}
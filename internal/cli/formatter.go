@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/dustin/go-humanize"
@@ -31,59 +33,76 @@ func PrintJSON(stats *dirstat.Stats, writer io.Writer) error {
 	return nil
 }
 
+// PrintEmpties outputs the empty files followed by the empty directories as a NUL-terminated list,
+// suitable for `xargs -0`.
+func PrintEmpties(stats *dirstat.Stats, writer io.Writer) error {
+	for _, path := range slices.Concat(stats.Empties.Files, stats.Empties.Dirs) {
+		if _, err := fmt.Fprintf(writer, "%s\x00", path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // PrintTable outputs statistics in human-readable table format.
 //
-//nolint:gocognit,varnamelen // Formatting logic requires multiple branches; w is idiomatic for writer
+//nolint:varnamelen // w is idiomatic for writer
 func PrintTable(stats *dirstat.Stats, writer io.Writer) error {
 	w := tabwriter.NewWriter(writer, 0, 4, TabSpacing, ' ', 0) //nolint:mnd // Tabwriter configuration
 
-	if !stats.DirectoryMode {
-		// Extension statistics
-		if _, err := fmt.Fprintln(w, "\nTop extensions:\t\t"); err != nil {
+	// Extensions shown in the table, smallest first
+	var displayList []string
+
+	switch {
+	case stats.GroupBy == dirstat.GroupByOwner:
+		if _, err := printBreakdown(w, "Top owners", stats.Owners.Users, stats); err != nil {
 			return err
 		}
 
-		extList := make([]string, 0, len(stats.ExtStats))
-		for ext := range stats.ExtStats {
-			extList = append(extList, ext)
+		if _, err := printBreakdown(w, "Top groups", stats.Owners.Groups, stats); err != nil {
+			return err
 		}
-
-		sort.Slice(extList, func(i, j int) bool {
-			return stats.ExtStats[extList[i]].Size < stats.ExtStats[extList[j]].Size
-		})
-
-		startIdx := 0
-
-		if len(extList) > stats.TopN {
-			startIdx = len(extList) - stats.TopN
+	case stats.GroupBy == dirstat.GroupByCategory:
+		if err := printCategories(w, stats); err != nil {
+			return err
+		}
+	case !stats.DirectoryMode:
+		list, err := printBreakdown(w, "Top extensions", stats.ExtStats, stats)
+		if err != nil {
+			return err
 		}
 
-		displayList := extList[startIdx:]
-		for i, ext := range displayList { //nolint:varnamelen // Standard loop index
-			extStat := stats.ExtStats[ext]
-			pct := 0.0
+		displayList = list
+	}
 
-			if stats.TotalBytes > 0 {
-				pct = 100.0 * float64(extStat.Size) / float64(stats.TotalBytes) //nolint:mnd // Percentage calculation
-			}
+	if len(stats.ContentTypes) > 0 {
+		if _, err := printBreakdown(w, "Top content types", stats.ContentTypes, stats); err != nil {
+			return err
+		}
+	}
 
-			if ext == "" {
-				ext = "\"\""
-			}
+	sections := []func() error{
+		func() error { return printTopFiles(w, stats) },
+		func() error { return printAges(w, stats) },
+		func() error { return printSizeDistribution(w, stats, displayList) },
+		func() error { return printPathLists(w, stats) },
+		func() error { return printSummary(w, stats) },
+	}
 
-			fmt.Fprintf(
-				w,
-				"  %d) %s:\t%d files, %s (%.1f%%)\n",
-				len(displayList)-i,
-				ext,
-				extStat.Count,
-				humanize.IBytes(uint64(extStat.Size)), //nolint:gosec // Size is always positive
-				pct,
-			)
+	for _, section := range sections {
+		if err := section(); err != nil {
+			return err
 		}
 	}
 
-	// Top files/directories
+	return w.Flush()
+}
+
+// printTopFiles prints the largest files or directories.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printTopFiles(w io.Writer, stats *dirstat.Stats) error {
 	if stats.DirectoryMode {
 		if _, err := fmt.Fprintln(w, "\nTop directories:\t\t"); err != nil {
 			return err
@@ -98,8 +117,8 @@ func PrintTable(stats *dirstat.Stats, writer io.Writer) error {
 		f := stats.TopFiles[i] //nolint:varnamelen // Common abbreviation for file
 		pct := 0.0
 
-		if stats.TotalBytes > 0 {
-			pct = 100.0 * float64(f.Size) / float64(stats.TotalBytes) //nolint:mnd // Percentage calculation
+		if total := stats.Total(); total > 0 {
+			pct = 100.0 * float64(f.Bytes(stats.DiskUsage)) / float64(total) //nolint:mnd // Percentage calculation
 		}
 
 		fmt.Fprintf(
@@ -107,16 +126,182 @@ func PrintTable(stats *dirstat.Stats, writer io.Writer) error {
 			"  %d) '%s'\t%s (%.1f%%)\n",
 			len(stats.TopFiles)-i,
 			f.Path,
-			humanize.IBytes(uint64(f.Size)), //nolint:gosec // Size is always positive
+			humanize.IBytes(uint64(f.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+			pct,
+		)
+	}
+
+	return nil
+}
+
+// printAges prints the non-empty age buckets.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printAges(w io.Writer, stats *dirstat.Stats) error {
+	if stats.FileCount == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "\nAge (%s):\t\t\n", stats.TimeField); err != nil {
+		return err
+	}
+
+	for _, bucket := range stats.AgeBuckets {
+		if bucket.Count == 0 {
+			continue
+		}
+
+		pct := 0.0
+
+		if total := stats.Total(); total > 0 {
+			pct = 100.0 * float64(bucket.Bytes(stats.DiskUsage)) / float64(total) //nolint:mnd // Percentage calculation
+		}
+
+		fmt.Fprintf(
+			w,
+			"  %s:\t%d files, %s (%.1f%%)\n",
+			bucket.Label,
+			bucket.Count,
+			humanize.IBytes(uint64(bucket.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
 			pct,
 		)
 	}
 
-	// Stats summary
+	return nil
+}
+
+// printSizeDistribution prints the non-empty size buckets as a histogram, followed by
+// a sparkline per extension in displayList.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printSizeDistribution(w io.Writer, stats *dirstat.Stats, displayList []string) error {
+	if len(stats.SizeHistogram) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(w, "\nSize distribution:\t\t"); err != nil {
+		return err
+	}
+
+	var maxCount int64
+	for _, bucket := range stats.SizeHistogram {
+		maxCount = max(maxCount, bucket.Count)
+	}
+
+	for _, bucket := range stats.SizeHistogram {
+		if bucket.Count == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "  %s:\t%s %d files\n", bucketLabel(bucket), histogramBar(bucket.Count, maxCount), bucket.Count)
+	}
+
+	if len(displayList) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(
+		w,
+		"\nSize distribution by extension (%s to %s):\n",
+		humanize.IBytes(uint64(stats.SizeHistogram[0].Min)),                          //nolint:gosec // Size is always positive
+		humanize.IBytes(uint64(stats.SizeHistogram[len(stats.SizeHistogram)-1].Max)), //nolint:gosec // Size is always positive
+	); err != nil {
+		return err
+	}
+
+	for _, ext := range displayList {
+		label := ext
+		if label == "" {
+			label = "\"\""
+		}
+
+		fmt.Fprintf(w, "  %s:\t%s\n", label, sparkline(stats.ExtHistograms[ext], stats.SizeHistogram))
+	}
+
+	return nil
+}
+
+// printPathLists prints the content mismatches, empty files and directories, skipped mount points
+// and unreadable paths.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printPathLists(w io.Writer, stats *dirstat.Stats) error {
+	if len(stats.Mismatches) > 0 {
+		if _, err := fmt.Fprintf(w, "\nContent mismatches (%d):\t\t\n", stats.MismatchCount); err != nil {
+			return err
+		}
+
+		for _, mismatch := range stats.Mismatches {
+			fmt.Fprintf(w, "  '%s'\t%s is %s, expected %s\n", mismatch.Path, mismatch.Ext, mismatch.Detected, mismatch.Expected)
+		}
+	}
+
+	if err := printPaths(w, "Empty files", stats.Empties.FileCount, stats.Empties.Files); err != nil {
+		return err
+	}
+
+	if err := printPaths(w, "Empty directories", stats.Empties.DirCount, stats.Empties.Dirs); err != nil {
+		return err
+	}
+
+	if len(stats.MountPoints) > 0 {
+		if _, err := fmt.Fprintln(w, "\nSkipped mount points:\t\t"); err != nil {
+			return err
+		}
+
+		for _, mount := range stats.MountPoints {
+			fmt.Fprintf(w, "  '%s'\n", mount)
+		}
+	}
+
+	if len(stats.Errors) > 0 {
+		if _, err := fmt.Fprintf(w, "\nUnreadable paths (%d):\t\t\n", stats.ErrorCount); err != nil {
+			return err
+		}
+
+		for _, walkErr := range stats.Errors {
+			fmt.Fprintf(w, "  '%s'\t%s\n", walkErr.Path, walkErr.Class)
+		}
+
+		if more := stats.ErrorCount - int64(len(stats.Errors)); more > 0 {
+			fmt.Fprintf(w, "  ... and %d more (see --max-errors)\n", more)
+		}
+	}
+
+	return nil
+}
+
+// printPaths prints count and the listed paths under title, if count is positive.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printPaths(w io.Writer, title string, count int64, paths []string) error {
+	if count == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "\n%s (%d):\t\t\n", title, count); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Fprintf(w, "  '%s'\n", path)
+	}
+
+	return nil
+}
+
+// printSummary prints the totals and counters.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printSummary(w io.Writer, stats *dirstat.Stats) error {
 	if _, err := fmt.Fprintln(w, "\nStats:\t\t"); err != nil {
 		return err
 	}
 
+	if stats.Interrupted {
+		fmt.Fprintf(w, "Status:\tinterrupted, results are incomplete\n")
+	}
+
 	if stats.DirectoryMode {
 		fmt.Fprintf(w, "Total directories:\t%d\n", stats.FileCount)
 	} else {
@@ -125,8 +310,232 @@ func PrintTable(stats *dirstat.Stats, writer io.Writer) error {
 
 	fmt.Fprintf(w, "Total size:\t%s (%d bytes)\n",
 		humanize.IBytes(uint64(stats.TotalBytes)), stats.TotalBytes) //nolint:gosec // Size is always positive
+	fmt.Fprintf(w, "Disk usage:\t%s (%d bytes)\n",
+		humanize.IBytes(uint64(stats.TotalDiskBytes)), stats.TotalDiskBytes) //nolint:gosec // Size is always positive
+
+	fmt.Fprintf(w, "Entries:\t%d regular, %d dir, %d symlink, %d other\n",
+		stats.EntryTypes[dirstat.EntryTypeRegular].Count,
+		stats.EntryTypes[dirstat.EntryTypeDir].Count,
+		stats.EntryTypes[dirstat.EntryTypeSymlink].Count,
+		stats.EntryTypes[dirstat.EntryTypeOther].Count,
+	)
+
+	if stats.Hidden.Count > 0 {
+		pct := 0.0
+
+		if total := stats.Total(); total > 0 {
+			pct = 100.0 * float64(stats.Hidden.Bytes(stats.DiskUsage)) / float64(total) //nolint:mnd // Percentage calculation
+		}
+
+		fmt.Fprintf(w, "Hidden:\t%d files, %s (%.1f%% of this tree)\n",
+			stats.Hidden.Count, humanize.IBytes(uint64(stats.Hidden.Bytes(stats.DiskUsage))), pct) //nolint:gosec // Size is always positive
+	}
+
+	if stats.ErrorCount > 0 {
+		fmt.Fprintf(w, "Unreadable paths:\t%d (%s)\n", stats.ErrorCount, errorSummary(stats.ErrorClasses))
+	}
+
+	if stats.HardLinks > 0 {
+		fmt.Fprintf(w, "Hard links skipped:\t%d (%s)\n",
+			stats.HardLinks, humanize.IBytes(uint64(stats.HardLinkBytes))) //nolint:gosec // Size is always positive
+	}
+
+	if stats.FollowedLinks > 0 {
+		fmt.Fprintf(w, "Symlinks followed:\t%d\n", stats.FollowedLinks)
+	}
+
+	if stats.LinkDuplicates > 0 {
+		fmt.Fprintf(w, "Symlinks skipped (duplicate target):\t%d\n", stats.LinkDuplicates)
+	}
+
+	if len(stats.LinkCycles) > 0 {
+		fmt.Fprintf(w, "Symlinks skipped (cycle):\t%d\n", len(stats.LinkCycles))
+	}
 
 	fmt.Fprintf(w, "\nElapsed:\t%v\n", stats.Elapsed)
 
-	return w.Flush()
+	return nil
+}
+
+// printCategories prints the categories followed by the largest files of each category.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printCategories(w io.Writer, stats *dirstat.Stats) error {
+	breakdown := make(map[string]dirstat.ExtStat, len(stats.Categories))
+	for category, stat := range stats.Categories {
+		breakdown[category] = stat.ExtStat
+	}
+
+	categories, err := printBreakdown(w, "Top categories", breakdown, stats)
+	if err != nil {
+		return err
+	}
+
+	if len(categories) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintln(w, "\nTop files by category:\t\t"); err != nil {
+		return err
+	}
+
+	// Largest category first
+	for _, category := range slices.Backward(categories) {
+		fmt.Fprintf(w, "  %s:\t\t\n", category)
+
+		files := stats.Categories[category].TopFiles
+		for i, file := range files {
+			pct := 0.0
+
+			if total := stats.Total(); total > 0 {
+				pct = 100.0 * float64(file.Bytes(stats.DiskUsage)) / float64(total) //nolint:mnd // Percentage calculation
+			}
+
+			fmt.Fprintf(
+				w,
+				"    %d) '%s'\t%s (%.1f%%)\n",
+				len(files)-i,
+				file.Path,
+				humanize.IBytes(uint64(file.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+				pct,
+			)
+		}
+	}
+
+	return nil
+}
+
+// printBreakdown prints the top entries of breakdown under title and returns their keys, smallest first.
+//
+//nolint:varnamelen // w is idiomatic for writer
+func printBreakdown(w io.Writer, title string, breakdown map[string]dirstat.ExtStat, stats *dirstat.Stats) ([]string, error) {
+	if _, err := fmt.Fprintf(w, "\n%s:\t\t\n", title); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(breakdown))
+	for key := range breakdown {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return breakdown[keys[i]].Bytes(stats.DiskUsage) < breakdown[keys[j]].Bytes(stats.DiskUsage)
+	})
+
+	startIdx := 0
+
+	if len(keys) > stats.TopN {
+		startIdx = len(keys) - stats.TopN
+	}
+
+	keys = keys[startIdx:]
+	for i, key := range keys { //nolint:varnamelen // Standard loop index
+		stat := breakdown[key]
+		pct := 0.0
+
+		if total := stats.Total(); total > 0 {
+			pct = 100.0 * float64(stat.Bytes(stats.DiskUsage)) / float64(total) //nolint:mnd // Percentage calculation
+		}
+
+		if key == "" {
+			key = "\"\""
+		}
+
+		fmt.Fprintf(
+			w,
+			"  %d) %s:\t%d files, %s (%.1f%%)\n",
+			len(keys)-i,
+			key,
+			stat.Count,
+			humanize.IBytes(uint64(stat.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+			pct,
+		)
+	}
+
+	return keys, nil
+}
+
+// histogramBarWidth is the width of the longest histogram bar.
+const histogramBarWidth = 30
+
+// histogramBar renders count as a bar relative to maxCount.
+func histogramBar(count, maxCount int64) string {
+	if maxCount <= 0 {
+		return ""
+	}
+
+	width := int(count * histogramBarWidth / maxCount)
+	if width == 0 && count > 0 {
+		width = 1
+	}
+
+	return strings.Repeat("#", width) + strings.Repeat(" ", histogramBarWidth-width)
+}
+
+// sparkline renders buckets as one character per non-empty bucket of the overall histogram.
+func sparkline(buckets, overall []dirstat.SizeBucket) string {
+	levels := []rune(" ▁▂▃▄▅▆▇█")
+
+	counts := make(map[int64]int64, len(buckets))
+
+	var maxCount int64
+
+	for _, bucket := range buckets {
+		counts[bucket.Min] = bucket.Count
+		maxCount = max(maxCount, bucket.Count)
+	}
+
+	var line strings.Builder
+
+	for _, bucket := range overall {
+		if bucket.Count == 0 {
+			continue
+		}
+
+		level := 0
+
+		if count := counts[bucket.Min]; count > 0 && maxCount > 0 {
+			level = max(1, int(count*int64(len(levels)-1)/maxCount))
+		}
+
+		line.WriteRune(levels[level])
+	}
+
+	return line.String()
+}
+
+// bucketLabel describes the size range of bucket.
+func bucketLabel(bucket dirstat.SizeBucket) string {
+	if bucket.Max <= 1 {
+		return "0 B"
+	}
+
+	return fmt.Sprintf(
+		"%s - %s",
+		humanize.IBytes(uint64(bucket.Min)), //nolint:gosec // Size is always positive
+		humanize.IBytes(uint64(bucket.Max)), //nolint:gosec // Size is always positive
+	)
+}
+
+// errorSummary formats the error counts per class, most frequent first.
+func errorSummary(classes map[string]int64) string {
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, class := range names {
+		parts = append(parts, fmt.Sprintf("%s: %d", class, classes[class]))
+	}
+
+	return strings.Join(parts, ", ")
 }
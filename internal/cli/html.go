@@ -0,0 +1,83 @@
+package cli
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/idelchi/dirstat/internal/dirstat"
+)
+
+// reportTemplate is the self-contained HTML report, including its styles and scripts.
+//
+//go:embed report.html
+var reportTemplate string
+
+// htmlReport is the data rendered into the HTML report.
+type htmlReport struct {
+	// Title names the report after the scan root.
+	Title string
+	// Generated is the time the report was written.
+	Generated string
+	// Stats are the statistics shown in the report.
+	Stats *dirstat.Stats
+	// Tree is the hierarchy shown in the treemap.
+	Tree *dirstat.TreeNode
+	// Breakdown lists the extensions, largest first.
+	Breakdown []NamedStat
+	// TopFiles lists the largest files or directories, largest first.
+	TopFiles []dirstat.FileStat
+	// Summary lists the totals and counters as name and value pairs.
+	Summary [][]string
+}
+
+// PrintHTML outputs statistics as a single offline HTML file with a zoomable treemap
+// of Stats.Tree, a sortable extension table and the summary.
+func PrintHTML(stats *dirstat.Stats, writer io.Writer) error {
+	funcs := template.FuncMap{
+		"humanBytes": func(size int64) string {
+			return humanize.IBytes(uint64(max(size, 0))) //nolint:gosec // Size is clamped to be positive
+		},
+		"percent": func(part int64) string {
+			return percent(part, stats.Total())
+		},
+		"add": func(a, b int) int {
+			return a + b
+		},
+	}
+
+	tmpl, err := template.New("report").Funcs(funcs).Parse(reportTemplate)
+	if err != nil {
+		return fmt.Errorf("parsing report template: %w", err)
+	}
+
+	// Without a hierarchy, the treemap stays empty
+	tree := stats.Tree
+	if tree == nil {
+		tree = &dirstat.TreeNode{Name: ".", Dir: true}
+	}
+
+	topFiles := slices.Clone(stats.TopFiles)
+	slices.Reverse(topFiles)
+
+	report := htmlReport{
+		Title:     tree.Name,
+		Generated: time.Now().Format(time.RFC3339),
+		Stats:     stats,
+		Tree:      tree,
+		Breakdown: sortStats(stats.ExtStats, stats.DiskUsage),
+		TopFiles:  topFiles,
+		Summary:   summaryRows(stats),
+	}
+
+	if err := tmpl.Execute(writer, report); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	return nil
+}
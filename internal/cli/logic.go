@@ -2,9 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/dustin/go-humanize"
 	"github.com/mattn/go-isatty"
@@ -13,11 +16,15 @@ import (
 )
 
 func logic(options dirstat.Options) error {
-	enableProgress := strings.ToLower(options.Output) != "json" &&
+	output := strings.ToLower(options.Output)
+
+	enableProgress := output != "json" && output != "ndjson" &&
 		!options.Debug &&
 		isatty.IsTerminal(os.Stderr.Fd())
 
-	ctx := context.Background()
+	// Cancel the walk on interrupt to report partial results
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Simple progress callback that prints directly to stderr
 	var progressHook func(files, bytes int64)
@@ -34,8 +41,19 @@ func logic(options dirstat.Options) error {
 		}
 	}
 
+	// Stream accepted files while the walk runs, unless the output is replaced by --print0
+	var stream *NDJSON
+
+	if output == "ndjson" && !options.Print0 {
+		stream = NewNDJSON(os.Stdout)
+		options.EntryHook = stream.Entry
+	}
+
 	stats, err := dirstat.Run(ctx, options, progressHook)
 
+	// Restore the default signal handling, so a second interrupt while printing terminates
+	stop()
+
 	// Clear the status line
 	if enableProgress {
 		fmt.Fprint(os.Stderr, "\r\033[2K\r")
@@ -45,12 +63,44 @@ func logic(options dirstat.Options) error {
 		return err
 	}
 
-	switch strings.ToLower(options.Output) {
-	case "json":
-		return PrintJSON(stats, os.Stdout)
-	case "table":
-		return PrintTable(stats, os.Stdout)
+	switch {
+	case options.Print0:
+		err = PrintEmpties(stats, os.Stdout)
+	case options.Format != "":
+		err = PrintTemplate(stats, os.Stdout, options.Format)
+	case output == "json":
+		err = PrintJSON(stats, os.Stdout)
+	case output == "ndjson":
+		err = stream.Summary(stats)
+	case output == "table":
+		err = PrintTable(stats, os.Stdout)
+	case output == "markdown":
+		err = PrintMarkdown(stats, os.Stdout, options.Collapse)
+	case output == "html":
+		err = PrintHTML(stats, os.Stdout)
+	case output == "csv":
+		err = PrintDelimited(stats, os.Stdout, ',', options.Records)
+	case output == "tsv":
+		err = PrintDelimited(stats, os.Stdout, '\t', options.Records)
 	default:
 		return fmt.Errorf("unknown output format: %s", options.Output)
 	}
+
+	if err != nil {
+		return err
+	}
+
+	if options.Print0 && stats.Empties.Truncated() {
+		return fmt.Errorf("empty paths truncated at --max-empties %d: the list is incomplete", options.MaxEmpties)
+	}
+
+	if stats.Interrupted {
+		return errors.New("scan interrupted: results are incomplete")
+	}
+
+	if options.Strict && stats.ErrorCount > 0 {
+		return fmt.Errorf("%d paths could not be read", stats.ErrorCount)
+	}
+
+	return nil
 }
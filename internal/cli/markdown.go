@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/idelchi/dirstat/internal/dirstat"
+)
+
+// markdownEscaper escapes text for use in a GitHub-flavored table cell.
+//
+//nolint:gochecknoglobals // Stateless replacer
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"<", "&lt;",
+	"\r", "",
+	"\n", "<br>",
+)
+
+// markdownTable accumulates the rows of a GitHub-flavored table. Cells are escaped when written.
+type markdownTable struct {
+	title  string
+	header []string
+	align  []string
+	rows   [][]string
+}
+
+// write renders the table under its title. Tables with more than collapse rows are
+// wrapped in a collapsible details block (0=never).
+func (t *markdownTable) write(writer io.Writer, collapse int) error {
+	var out strings.Builder
+
+	details := collapse > 0 && len(t.rows) > collapse
+
+	fmt.Fprintf(&out, "### %s\n\n", t.title)
+
+	if details {
+		fmt.Fprintf(&out, "<details>\n<summary>%d entries</summary>\n\n", len(t.rows))
+	}
+
+	fmt.Fprintf(&out, "| %s |\n", strings.Join(t.header, " | "))
+	fmt.Fprintf(&out, "| %s |\n", strings.Join(t.align, " | "))
+
+	for _, row := range t.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = markdownEscaper.Replace(cell)
+		}
+
+		fmt.Fprintf(&out, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	if details {
+		out.WriteString("\n</details>\n")
+	}
+
+	out.WriteString("\n")
+
+	if _, err := io.WriteString(writer, out.String()); err != nil {
+		return fmt.Errorf("writing markdown: %w", err)
+	}
+
+	return nil
+}
+
+// PrintMarkdown outputs statistics as GitHub-flavored Markdown tables.
+// Lists with more than collapse rows are wrapped in a collapsible details block (0=never).
+func PrintMarkdown(stats *dirstat.Stats, writer io.Writer, collapse int) error {
+	var tables []*markdownTable
+
+	switch {
+	case stats.GroupBy == dirstat.GroupByOwner:
+		tables = append(tables,
+			breakdownTable("Top owners", "Owner", stats.Owners.Users, stats),
+			breakdownTable("Top groups", "Group", stats.Owners.Groups, stats),
+		)
+	case stats.GroupBy == dirstat.GroupByCategory:
+		categories := make(map[string]dirstat.ExtStat, len(stats.Categories))
+		for category, stat := range stats.Categories {
+			categories[category] = stat.ExtStat
+		}
+
+		tables = append(tables, breakdownTable("Top categories", "Category", categories, stats))
+	case !stats.DirectoryMode:
+		tables = append(tables, breakdownTable("Top extensions", "Extension", stats.ExtStats, stats))
+	}
+
+	if stats.DirectoryMode {
+		tables = append(tables, pathTable("Top directories", "Directory", stats))
+	} else {
+		tables = append(tables, pathTable("Top files", "File", stats))
+	}
+
+	for _, table := range tables {
+		if err := table.write(writer, collapse); err != nil {
+			return err
+		}
+	}
+
+	// The summary is short, never collapse it
+	return statsTable(stats).write(writer, 0)
+}
+
+// breakdownTable lists the top entries of breakdown, largest first.
+func breakdownTable(title, column string, breakdown map[string]dirstat.ExtStat, stats *dirstat.Stats) *markdownTable {
+	keys := make([]string, 0, len(breakdown))
+	for key := range breakdown {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		bytesI, bytesJ := breakdown[keys[i]].Bytes(stats.DiskUsage), breakdown[keys[j]].Bytes(stats.DiskUsage)
+		if bytesI != bytesJ {
+			return bytesI > bytesJ
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	if len(keys) > stats.TopN {
+		keys = keys[:stats.TopN]
+	}
+
+	table := &markdownTable{
+		title:  title,
+		header: []string{"#", column, "Files", "Size", "Share"},
+		align:  []string{"---:", "---", "---:", "---:", "---:"},
+	}
+
+	for i, key := range keys {
+		stat := breakdown[key]
+
+		name := key
+		if name == "" {
+			name = `""`
+		}
+
+		table.rows = append(table.rows, []string{
+			fmt.Sprint(i + 1),
+			name,
+			fmt.Sprint(stat.Count),
+			humanize.IBytes(uint64(stat.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+			percent(stat.Bytes(stats.DiskUsage), stats.Total()) + "%",
+		})
+	}
+
+	return table
+}
+
+// pathTable lists the top files or directories, largest first.
+func pathTable(title, column string, stats *dirstat.Stats) *markdownTable {
+	table := &markdownTable{
+		title:  title,
+		header: []string{"#", column, "Size", "Share"},
+		align:  []string{"---:", "---", "---:", "---:"},
+	}
+
+	for i, file := range slices.Backward(stats.TopFiles) {
+		table.rows = append(table.rows, []string{
+			fmt.Sprint(len(stats.TopFiles) - i),
+			file.Path,
+			humanize.IBytes(uint64(file.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+			percent(file.Bytes(stats.DiskUsage), stats.Total()) + "%",
+		})
+	}
+
+	return table
+}
+
+// statsTable lists the totals and counters.
+func statsTable(stats *dirstat.Stats) *markdownTable {
+	return &markdownTable{
+		title:  "Stats",
+		header: []string{"Stat", "Value"},
+		align:  []string{"---", "---:"},
+		rows:   summaryRows(stats),
+	}
+}
+
+// summaryRows returns the totals and counters as name and value pairs.
+func summaryRows(stats *dirstat.Stats) [][]string {
+	var rows [][]string
+
+	row := func(name, format string, args ...any) {
+		rows = append(rows, []string{name, fmt.Sprintf(format, args...)})
+	}
+
+	if stats.Interrupted {
+		row("Status", "interrupted, results are incomplete")
+	}
+
+	if stats.DirectoryMode {
+		row("Total directories", "%d", stats.FileCount)
+	} else {
+		row("Total files", "%d", stats.FileCount)
+	}
+
+	row("Total size", "%s (%d bytes)", humanize.IBytes(uint64(stats.TotalBytes)), stats.TotalBytes)         //nolint:gosec // Size is always positive
+	row("Disk usage", "%s (%d bytes)", humanize.IBytes(uint64(stats.TotalDiskBytes)), stats.TotalDiskBytes) //nolint:gosec // Size is always positive
+	row("Entries", "%d regular, %d dir, %d symlink, %d other",
+		stats.EntryTypes[dirstat.EntryTypeRegular].Count,
+		stats.EntryTypes[dirstat.EntryTypeDir].Count,
+		stats.EntryTypes[dirstat.EntryTypeSymlink].Count,
+		stats.EntryTypes[dirstat.EntryTypeOther].Count,
+	)
+
+	if stats.Hidden.Count > 0 {
+		row("Hidden", "%d files, %s (%s%% of this tree)",
+			stats.Hidden.Count,
+			humanize.IBytes(uint64(stats.Hidden.Bytes(stats.DiskUsage))), //nolint:gosec // Size is always positive
+			percent(stats.Hidden.Bytes(stats.DiskUsage), stats.Total()))
+	}
+
+	if stats.ErrorCount > 0 {
+		row("Unreadable paths", "%d (%s)", stats.ErrorCount, errorSummary(stats.ErrorClasses))
+	}
+
+	if stats.HardLinks > 0 {
+		row("Hard links skipped", "%d (%s)", stats.HardLinks, humanize.IBytes(uint64(stats.HardLinkBytes))) //nolint:gosec // Size is always positive
+	}
+
+	if stats.FollowedLinks > 0 {
+		row("Symlinks followed", "%d", stats.FollowedLinks)
+	}
+
+	if stats.LinkDuplicates > 0 {
+		row("Symlinks skipped (duplicate target)", "%d", stats.LinkDuplicates)
+	}
+
+	if len(stats.LinkCycles) > 0 {
+		row("Symlinks skipped (cycle)", "%d", len(stats.LinkCycles))
+	}
+
+	row("Elapsed", "%v", stats.Elapsed)
+
+	return rows
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/idelchi/dirstat/internal/dirstat"
+)
+
+// Record types of the NDJSON output.
+const (
+	recordFile    = "file"
+	recordSummary = "summary"
+)
+
+// NDJSON streams accepted files as newline-delimited JSON while the walk runs,
+// followed by a summary record with the aggregate statistics.
+type NDJSON struct {
+	out *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+// NewNDJSON creates an NDJSON stream writing to writer.
+func NewNDJSON(writer io.Writer) *NDJSON {
+	out := bufio.NewWriter(writer)
+
+	return &NDJSON{out: out, enc: json.NewEncoder(out)}
+}
+
+// Entry writes a file record. It is meant to be used as dirstat.Options.EntryHook.
+// The first write error is kept and reported by Summary.
+func (n *NDJSON) Entry(entry dirstat.Entry) {
+	if n.err != nil {
+		return
+	}
+
+	n.err = n.enc.Encode(struct {
+		Record string `json:"record"`
+		dirstat.Entry
+	}{Record: recordFile, Entry: entry})
+}
+
+// Summary writes the summary record and flushes the stream.
+func (n *NDJSON) Summary(stats *dirstat.Stats) error {
+	if n.err == nil {
+		n.err = n.enc.Encode(struct {
+			Record string `json:"record"`
+			*dirstat.Stats
+		}{Record: recordSummary, Stats: stats})
+	}
+
+	if n.err == nil {
+		n.err = n.out.Flush()
+	}
+
+	if n.err != nil {
+		return fmt.Errorf("writing NDJSON output: %w", n.err)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"text/template"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/idelchi/dirstat/internal/dirstat"
+)
+
+// NamedStat is a map entry of an ExtStat map, as returned by the sortStats template function.
+type NamedStat struct {
+	// Name is the map key, such as an extension, owner or category.
+	Name string
+
+	dirstat.ExtStat
+}
+
+// templateFuncs are the helper functions available to --format templates.
+// Statistics are sorted by allocated size if diskUsage is set, otherwise by apparent size.
+func templateFuncs(diskUsage bool) template.FuncMap {
+	return template.FuncMap{
+		// humanBytes formats a size with binary units, e.g. 1.5 MiB
+		"humanBytes": func(size int64) string {
+			return humanize.IBytes(uint64(max(size, 0))) //nolint:gosec // Size is clamped to be positive
+		},
+		// siBytes formats a size with decimal units, e.g. 1.6 MB
+		"siBytes": func(size int64) string {
+			return humanize.Bytes(uint64(max(size, 0))) //nolint:gosec // Size is clamped to be positive
+		},
+		// percent returns part as a percentage of total, for any numeric types
+		"percent": func(part, total any) (float64, error) {
+			partValue, err := toFloat(part)
+			if err != nil {
+				return 0, err
+			}
+
+			totalValue, err := toFloat(total)
+			if err != nil {
+				return 0, err
+			}
+
+			if totalValue <= 0 {
+				return 0, nil
+			}
+
+			return 100.0 * partValue / totalValue, nil //nolint:mnd // Percentage calculation
+		},
+		// sortStats turns a map of statistics into a slice, largest first
+		"sortStats": func(stats map[string]dirstat.ExtStat) []NamedStat {
+			return sortStats(stats, diskUsage)
+		},
+		// reverse returns a reversed copy of a slice, e.g. to list TopFiles largest first
+		"reverse": reverse,
+	}
+}
+
+// ParseTemplate parses a --format template with the helper functions.
+func ParseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs(false)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing format template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// PrintTemplate outputs statistics by executing a --format template against them.
+func PrintTemplate(stats *dirstat.Stats, writer io.Writer, text string) error {
+	tmpl, err := ParseTemplate(text)
+	if err != nil {
+		return err
+	}
+
+	// Sort by the size selected for the scan
+	tmpl.Funcs(templateFuncs(stats.DiskUsage))
+
+	if err := tmpl.Execute(writer, stats); err != nil {
+		return fmt.Errorf("executing format template: %w", err)
+	}
+
+	return nil
+}
+
+// sortStats returns the entries of stats ordered by size, largest first, and by name for equal sizes.
+// The allocated size is used if diskUsage is set, otherwise the apparent size.
+func sortStats(stats map[string]dirstat.ExtStat, diskUsage bool) []NamedStat {
+	named := make([]NamedStat, 0, len(stats))
+	for name, stat := range stats {
+		named = append(named, NamedStat{Name: name, ExtStat: stat})
+	}
+
+	sort.Slice(named, func(i, j int) bool {
+		bytesI, bytesJ := named[i].Bytes(diskUsage), named[j].Bytes(diskUsage)
+		if bytesI != bytesJ {
+			return bytesI > bytesJ
+		}
+
+		return named[i].Name < named[j].Name
+	})
+
+	return named
+}
+
+// reverse returns a reversed copy of the slice list.
+func reverse(list any) (any, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("reverse: expected a slice, got %T", list)
+	}
+
+	reversed := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+	for i := range value.Len() {
+		reversed.Index(value.Len() - 1 - i).Set(value.Index(i))
+	}
+
+	return reversed.Interface(), nil
+}
+
+// toFloat converts a numeric template argument to float64.
+func toFloat(value any) (float64, error) {
+	number := reflect.ValueOf(value)
+
+	switch number.Kind() { //nolint:exhaustive // Only numeric kinds are accepted
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(number.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(number.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return number.Float(), nil
+	default:
+		return 0, fmt.Errorf("percent: expected a number, got %T", value)
+	}
+}
@@ -0,0 +1,121 @@
+package dirstat
+
+import (
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time fields selectable for age filters and buckets.
+const (
+	// TimeFieldMtime selects the modification time.
+	TimeFieldMtime = "mtime"
+	// TimeFieldAtime selects the access time.
+	TimeFieldAtime = "atime"
+	// TimeFieldCtime selects the status change time.
+	TimeFieldCtime = "ctime"
+)
+
+const (
+	// day is the length of a day.
+	day = 24 * time.Hour
+	// week is the length of a week.
+	week = 7 * day
+	// month is the approximate length of a month.
+	month = 30 * day
+	// year is the approximate length of a year.
+	year = 365 * day
+)
+
+// ageBucketLimits are the upper bounds of the age buckets; older files fall into a final bucket.
+//
+//nolint:gochecknoglobals // Fixed bucket layout shared by collector and stats
+var ageBucketLimits = [...]time.Duration{day, week, month, year}
+
+// ageBucketLabels are the labels of the age buckets, including the final bucket.
+//
+//nolint:gochecknoglobals // Fixed bucket layout shared by collector and stats
+var ageBucketLabels = [len(ageBucketLimits) + 1]string{"< 1d", "< 1w", "< 1mo", "< 1y", ">= 1y"}
+
+// AgeBucket holds the statistics of files within an age range.
+type AgeBucket struct {
+	// Label describes the age range (e.g. "< 1w").
+	Label string `json:"label"`
+
+	ExtStat
+}
+
+// ageBucket returns the index of the bucket for age.
+func ageBucket(age time.Duration) int {
+	for i, limit := range ageBucketLimits {
+		if age < limit {
+			return i
+		}
+	}
+
+	return len(ageBucketLimits)
+}
+
+// newAgeBuckets labels the collected bucket statistics.
+func newAgeBuckets(stats [len(ageBucketLimits) + 1]ExtStat) []AgeBucket {
+	buckets := make([]AgeBucket, len(stats))
+	for i, stat := range stats {
+		buckets[i] = AgeBucket{Label: ageBucketLabels[i], ExtStat: stat}
+	}
+
+	return buckets
+}
+
+// fileTime returns the timestamp of info selected by field.
+// Unsupported fields fall back to the modification time.
+func fileTime(info fs.FileInfo, field string) time.Time {
+	switch field {
+	case TimeFieldAtime:
+		if atime, _, ok := statTimes(info); ok {
+			return atime
+		}
+	case TimeFieldCtime:
+		if _, ctime, ok := statTimes(info); ok {
+			return ctime
+		}
+	}
+
+	return info.ModTime()
+}
+
+// ParseDuration parses a duration such as "90d", "2w", "6mo", "1y" or "36h".
+// In addition to the units of time.ParseDuration, it supports
+// days (d), weeks (w), months (mo) and years (y) for a single integer value.
+// A single integer with the unit m is rejected, since it reads as months but means minutes.
+func ParseDuration(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": day, "w": week, "mo": month, "y": year}
+
+	for suffix, unit := range units {
+		number, found := strings.CutSuffix(value, suffix)
+		if !found {
+			continue
+		}
+
+		count, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+		}
+
+		return time.Duration(count) * unit, nil
+	}
+
+	if number, found := strings.CutSuffix(value, "m"); found {
+		if _, err := strconv.ParseInt(number, 10, 64); err == nil {
+			return 0, fmt.Errorf("ambiguous duration %q: use mo for months, or e.g. %sm0s for minutes", value, number)
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+
+	return duration, nil
+}
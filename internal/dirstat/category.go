@@ -0,0 +1,138 @@
+package dirstat
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CategoryOther collects the files that match no category.
+const CategoryOther = "other"
+
+// categoryTopN is the number of largest files tracked per category.
+const categoryTopN = 5
+
+// DefaultCategories maps categories to the extensions (starting with '.') and
+// file name globs they contain.
+//
+//nolint:gochecknoglobals // Default configuration
+var DefaultCategories = map[string][]string{
+	"source": {
+		".go", ".c", ".h", ".cc", ".cpp", ".hpp", ".rs", ".py", ".js", ".mjs", ".ts", ".d.ts", ".jsx", ".tsx",
+		".java", ".kt", ".scala", ".rb", ".php", ".cs", ".swift", ".lua", ".zig", ".sh", ".bash", ".zsh", ".ps1",
+		".css", ".scss", ".html", ".vue", ".svelte", ".sql", ".proto",
+		"Makefile", "Dockerfile", "*.mk",
+	},
+	"media": {
+		".jpg", ".jpeg", ".png", ".gif", ".webp", ".svg", ".bmp", ".tif", ".tiff", ".ico", ".heic", ".psd",
+		".mp3", ".wav", ".flac", ".ogg", ".aac", ".m4a", ".mp4", ".mkv", ".mov", ".avi", ".webm",
+	},
+	"archives": {
+		".zip", ".tar", ".gz", ".tgz", ".tar.gz", ".bz2", ".tar.bz2", ".xz", ".tar.xz", ".zst", ".tar.zst",
+		".7z", ".rar", ".jar", ".whl", ".deb", ".rpm", ".iso", ".dmg",
+	},
+	"binaries": {".exe", ".dll", ".so", ".dylib", ".bin", ".wasm", ".msi", ".app"},
+	"build":    {".o", ".a", ".obj", ".lib", ".class", ".pyc", ".pdb", ".map"},
+	"docs": {
+		".md", ".txt", ".rst", ".adoc", ".tex", ".pdf", ".doc", ".docx", ".odt", ".rtf", ".epub",
+		".xls", ".xlsx", ".ods", ".ppt", ".pptx", ".odp",
+		"README*", "LICENSE*", "CHANGELOG*", "NOTICE*",
+	},
+	"data": {".json", ".yml", ".yaml", ".toml", ".ini", ".xml", ".csv", ".tsv", ".parquet", ".db", ".sqlite", ".sqlite3"},
+}
+
+// CategoryStat holds the statistics and largest files of a category.
+//
+//nolint:tagliatelle // Using snake_case for JSON compatibility
+type CategoryStat struct {
+	ExtStat
+
+	// TopFiles contains the largest files of the category.
+	TopFiles []FileStat `json:"top_files"`
+}
+
+// namePattern assigns files whose name matches a glob to a category.
+type namePattern struct {
+	glob     string
+	category string
+}
+
+// categorizer assigns files to categories by name and normalized extension.
+type categorizer struct {
+	exts     *extNormalizer
+	byExt    map[string]string
+	patterns []namePattern
+}
+
+// newCategorizer creates a categorizer from the default table, with overrides taking
+// precedence. Extensions are normalized with exts, so aliases and case folding apply.
+func newCategorizer(overrides map[string][]string, exts *extNormalizer) *categorizer {
+	c := &categorizer{exts: exts, byExt: make(map[string]string)}
+
+	var defaults []namePattern
+
+	for _, category := range sortedKeys(DefaultCategories) {
+		defaults = append(defaults, c.register(category, DefaultCategories[category])...)
+	}
+
+	for _, category := range sortedKeys(overrides) {
+		c.patterns = append(c.patterns, c.register(category, overrides[category])...)
+	}
+
+	// Name patterns of the overrides are checked first
+	c.patterns = append(c.patterns, defaults...)
+
+	return c
+}
+
+// register maps the extensions of entries to category and returns its name patterns.
+func (c *categorizer) register(category string, entries []string) []namePattern {
+	var patterns []namePattern
+
+	for _, entry := range entries {
+		if strings.HasPrefix(entry, ".") {
+			c.byExt[c.exts.normalize(entry)] = category
+
+			continue
+		}
+
+		patterns = append(patterns, namePattern{glob: c.exts.fold(entry), category: category})
+	}
+
+	return patterns
+}
+
+// of returns the category of the file at filePath with the normalized extension ext.
+// Name patterns take precedence over extensions.
+func (c *categorizer) of(filePath, ext string) string {
+	name := c.exts.fold(filepath.Base(filePath))
+
+	for _, pattern := range c.patterns {
+		if matched, _ := filepath.Match(pattern.glob, name); matched {
+			return pattern.category
+		}
+	}
+
+	if category, ok := c.byExt[ext]; ok {
+		return category
+	}
+
+	// Categories describe content, so fall back to a case-insensitive lookup
+	if category, ok := c.byExt[strings.ToLower(ext)]; ok {
+		return category
+	}
+
+	return CategoryOther
+}
+
+// sortedKeys returns the keys of m in lexical order.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
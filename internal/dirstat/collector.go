@@ -0,0 +1,536 @@
+package dirstat
+
+import (
+	"hash/maphash"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shardsPerProc is the number of collector shards per available processor.
+const shardsPerProc = 4
+
+// fileKey uniquely identifies a file on the system by device and inode.
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+// shard holds a partition of the per-entry statistics. Entries are assigned to shards by
+// hashing their key, which spreads the concurrent fastwalk callbacks over many mutexes.
+type shard struct {
+	mu         sync.Mutex // Protect concurrent access
+	extStats   map[string]ExtStat
+	topFiles   *topFiles
+	inodes     map[fileKey]struct{}
+	ageBuckets [len(ageBucketLimits) + 1]ExtStat
+	sizeHist   sizeHistogram
+	extHist    map[string]sizeHistogram
+	users      map[uint32]ExtStat
+	groups     map[uint32]ExtStat
+	hidden     ExtStat
+	types      map[string]ExtStat
+	categories map[string]ExtStat
+	catTop     map[string]*topFiles
+	dirs       map[string]dirState
+	entryTypes map[string]ExtStat
+	tree       map[string]*treeDir
+	fileCount  int64
+	totalBytes int64
+	totalDisk  int64
+}
+
+// collector aggregates statistics from concurrent fastwalk callbacks.
+// Per-entry statistics are spread over shards and merged in finalize,
+// while rarely updated counters share a single mutex.
+type collector struct {
+	mu            sync.Mutex // Protect concurrent access to the shared counters
+	topN          int
+	maxErrors     int
+	directoryMode bool
+	diskUsage     bool
+	timeField     string
+	groupBy       string
+	empties       bool
+	maxEmpties    int
+	tree          bool
+	seed          maphash.Seed
+	shards        []*shard
+	errorCount    int64
+	errors        []WalkError
+	errorClasses  map[string]int64
+	hardLinks     int64
+	hardLinkBytes int64
+	followed      int64
+	linkDupes     int64
+	linkCycles    []string
+	mountPoints   []string
+	mismatches    []Mismatch
+	mismatchCount int64
+	emptyFiles    int64
+	emptyPaths    []string
+}
+
+// newCollector creates a collector configured by opt.
+func newCollector(opt Options) *collector {
+	shards := make([]*shard, runtime.GOMAXPROCS(0)*shardsPerProc)
+	for i := range shards {
+		shards[i] = &shard{
+			extStats:   make(map[string]ExtStat),
+			topFiles:   newTopFiles(opt.TopN, opt.DiskUsage),
+			inodes:     make(map[fileKey]struct{}),
+			extHist:    make(map[string]sizeHistogram),
+			types:      make(map[string]ExtStat),
+			categories: make(map[string]ExtStat),
+			catTop:     make(map[string]*topFiles),
+			dirs:       make(map[string]dirState),
+			entryTypes: make(map[string]ExtStat),
+			tree:       make(map[string]*treeDir),
+		}
+
+		if opt.collectsOwners() {
+			shards[i].users = make(map[uint32]ExtStat)
+			shards[i].groups = make(map[uint32]ExtStat)
+		}
+	}
+
+	return &collector{
+		topN:          opt.TopN,
+		maxErrors:     opt.MaxErrors,
+		directoryMode: opt.DirsMode,
+		diskUsage:     opt.DiskUsage,
+		timeField:     opt.TimeField,
+		groupBy:       opt.GroupBy,
+		empties:       opt.Empties,
+		maxEmpties:    opt.MaxEmpties,
+		tree:          opt.Tree,
+		seed:          maphash.MakeSeed(),
+		shards:        shards,
+		linkCycles:    make([]string, 0),
+		mountPoints:   make([]string, 0),
+		mismatches:    make([]Mismatch, 0),
+		emptyPaths:    make([]string, 0),
+		errors:        make([]WalkError, 0),
+		errorClasses:  make(map[string]int64),
+	}
+}
+
+// shardFor returns the shard responsible for key.
+func (c *collector) shardFor(key string) *shard {
+	return c.shards[maphash.String(c.seed, key)%uint64(len(c.shards))]
+}
+
+// progress returns the number of entries and bytes collected so far.
+func (c *collector) progress() (int64, int64) {
+	var files, bytes int64
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+		files += s.fileCount
+		bytes += s.totalBytes
+		s.mu.Unlock()
+	}
+
+	return files, bytes
+}
+
+// claimInode records key as seen and reports whether it was seen for the first time.
+func (c *collector) claimInode(key fileKey) bool {
+	s := c.shards[key.ino%uint64(len(c.shards))]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, seen := s.inodes[key]; seen {
+		return false
+	}
+
+	s.inodes[key] = struct{}{}
+
+	return true
+}
+
+// addHardLink records a skipped hard-linked duplicate of the given size.
+func (c *collector) addHardLink(size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hardLinks++
+	c.hardLinkBytes += size
+}
+
+// addFollowedLink increments the followed symlink counter.
+func (c *collector) addFollowedLink() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.followed++
+}
+
+// addLinkDuplicate increments the counter of symlinks whose target was already counted.
+func (c *collector) addLinkDuplicate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.linkDupes++
+}
+
+// addLinkCycle records a symlink that leads back to one of its ancestors.
+func (c *collector) addLinkCycle(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.linkCycles = append(c.linkCycles, strings.TrimPrefix(filepath.ToSlash(path), "./"))
+}
+
+// addMountPoint records a directory that was pruned because it is on another filesystem.
+func (c *collector) addMountPoint(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mountPoints = append(c.mountPoints, strings.TrimPrefix(filepath.ToSlash(path), "./"))
+}
+
+// addMismatch records a file whose content disagrees with its extension.
+// Only the first maxMismatches are kept, but all of them are counted.
+func (c *collector) addMismatch(mismatch Mismatch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mismatchCount++
+
+	if len(c.mismatches) < maxMismatches {
+		mismatch.Path = strings.TrimPrefix(filepath.ToSlash(mismatch.Path), "./")
+		c.mismatches = append(c.mismatches, mismatch)
+	}
+}
+
+// addError records a path that could not be read. This operation is protected by a mutex
+// since fastwalk calls the callback from multiple goroutines concurrently.
+// Only the first maxErrors errors are kept, but all of them are counted.
+func (c *collector) addError(path string, err error) {
+	walkErr := newWalkError(path, err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errorCount++
+	c.errorClasses[walkErr.Class]++
+
+	if len(c.errors) < c.maxErrors {
+		c.errors = append(c.errors, walkErr)
+	}
+}
+
+// entry describes a file accepted by the walk.
+type entry struct {
+	// path is the display path of the file.
+	path string
+	// ext is the file extension.
+	ext string
+	// size is the apparent size in bytes.
+	size int64
+	// diskSize is the allocated size in bytes.
+	diskSize int64
+	// age is the time since the selected timestamp of the file.
+	age time.Duration
+	// uid is the owning user ID, valid if hasOwner is set.
+	uid uint32
+	// gid is the owning group ID, valid if hasOwner is set.
+	gid uint32
+	// hasOwner indicates whether the owner is known.
+	hasOwner bool
+	// hidden indicates whether the file is below a hidden path.
+	hidden bool
+	// category is the category of the file.
+	category string
+}
+
+// add records a file. This operation is protected by the mutex of the responsible
+// shard since fastwalk calls the callback from multiple goroutines concurrently.
+func (c *collector) add(file entry) {
+	s := c.shardFor(file.path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record(file)
+
+	s.fileCount++
+
+	s.extStats[file.ext] = s.extStats[file.ext].with(file)
+
+	// Keep only the largest files, overall and per category
+	s.topFiles.offer(FileStat{Path: file.path, Size: file.size, DiskSize: file.diskSize})
+	s.offerCategory(file, c.diskUsage)
+}
+
+// offerCategory offers a file to the largest files of its category.
+// The caller must hold the mutex.
+func (s *shard) offerCategory(file entry, diskUsage bool) {
+	top, ok := s.catTop[file.category]
+	if !ok {
+		top = newTopFiles(categoryTopN, diskUsage)
+		s.catTop[file.category] = top
+	}
+
+	top.offer(FileStat{Path: file.path, Size: file.size, DiskSize: file.diskSize})
+}
+
+// addContentType records the content type detected for a file.
+func (c *collector) addContentType(file entry, content string) {
+	s := c.shardFor(file.path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.types[content] = s.types[content].with(file)
+}
+
+// addRollup records a file in directory mode, crediting its size to every directory in dirs.
+// Each directory is updated under the mutex of its own shard.
+func (c *collector) addRollup(dirs []string, file entry) {
+	for i, dir := range dirs {
+		s := c.shardFor(dir)
+
+		s.mu.Lock()
+
+		// Count the totals and the category files only once per file
+		if i == 0 {
+			s.record(file)
+			s.offerCategory(file, c.diskUsage)
+		}
+
+		s.creditDir(dir, file.size, file.diskSize)
+		s.mu.Unlock()
+	}
+}
+
+// record accumulates the per-file totals and breakdowns.
+// The caller must hold the mutex.
+func (s *shard) record(file entry) {
+	s.totalBytes += file.size
+	s.totalDisk += file.diskSize
+
+	bucket := ageBucket(file.age)
+	s.ageBuckets[bucket] = s.ageBuckets[bucket].with(file)
+
+	s.sizeHist.add(file.size)
+
+	hist := s.extHist[file.ext]
+	hist.add(file.size)
+	s.extHist[file.ext] = hist
+
+	s.categories[file.category] = s.categories[file.category].with(file)
+
+	if file.hidden {
+		s.hidden = s.hidden.with(file)
+	}
+
+	// Owners are only aggregated if the shard tracks them
+	if file.hasOwner && s.users != nil {
+		s.users[file.uid] = s.users[file.uid].with(file)
+		s.groups[file.gid] = s.groups[file.gid].with(file)
+	}
+}
+
+// with returns e with file added.
+func (e ExtStat) with(file entry) ExtStat {
+	e.Count++
+	e.Size += file.size
+	e.DiskSize += file.diskSize
+
+	return e
+}
+
+// mergeStats adds the statistics of src to dst.
+func mergeStats[K comparable](dst, src map[K]ExtStat) {
+	for key, stat := range src {
+		merged := dst[key]
+		merged.Count += stat.Count
+		merged.Size += stat.Size
+		merged.DiskSize += stat.DiskSize
+		dst[key] = merged
+	}
+}
+
+// creditDir accumulates a file's sizes into the directory at path.
+// The caller must hold the mutex.
+func (s *shard) creditDir(path string, size, diskSize int64) {
+	stat := s.extStats[path]
+	if stat.Count == 0 {
+		s.fileCount++
+	}
+
+	stat.Count++
+
+	stat.Size += size
+	stat.DiskSize += diskSize
+
+	s.extStats[path] = stat
+}
+
+// finalize produces the final Stats from the collected data.
+// It merges the shards, extracts the top N files or directories by size and
+// converts paths to slash format for cross-platform consistency.
+func (c *collector) finalize() *Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		extStats   = make(map[string]ExtStat)
+		top        = newTopFiles(c.topN, c.diskUsage)
+		fileCount  int64
+		totalBytes int64
+		totalDisk  int64
+		ageBuckets [len(ageBucketLimits) + 1]ExtStat
+		sizeHist   sizeHistogram
+		extHist    = make(map[string]sizeHistogram)
+		users      = make(map[uint32]ExtStat)
+		groups     = make(map[uint32]ExtStat)
+		hidden     ExtStat
+		types      = make(map[string]ExtStat)
+		categories = make(map[string]ExtStat)
+		catTop     = make(map[string]*topFiles)
+		entryTypes = map[string]ExtStat{
+			EntryTypeRegular: {},
+			EntryTypeDir:     {},
+			EntryTypeSymlink: {},
+			EntryTypeOther:   {},
+		}
+	)
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+
+		mergeStats(extStats, s.extStats)
+
+		for _, file := range s.topFiles.items {
+			top.offer(file)
+		}
+
+		for i, bucket := range s.ageBuckets {
+			ageBuckets[i].Count += bucket.Count
+			ageBuckets[i].Size += bucket.Size
+			ageBuckets[i].DiskSize += bucket.DiskSize
+		}
+
+		sizeHist.merge(s.sizeHist)
+
+		for ext, hist := range s.extHist {
+			merged := extHist[ext]
+			merged.merge(hist)
+			extHist[ext] = merged
+		}
+
+		hidden.Count += s.hidden.Count
+		hidden.Size += s.hidden.Size
+		hidden.DiskSize += s.hidden.DiskSize
+
+		mergeStats(types, s.types)
+
+		mergeStats(categories, s.categories)
+
+		mergeStats(entryTypes, s.entryTypes)
+
+		for category, top := range s.catTop {
+			merged, ok := catTop[category]
+			if !ok {
+				merged = newTopFiles(categoryTopN, c.diskUsage)
+				catTop[category] = merged
+			}
+
+			for _, file := range top.items {
+				merged.offer(file)
+			}
+		}
+
+		mergeStats(users, s.users)
+		mergeStats(groups, s.groups)
+
+		fileCount += s.fileCount
+		totalBytes += s.totalBytes
+		totalDisk += s.totalDisk
+
+		s.mu.Unlock()
+	}
+
+	if c.directoryMode {
+		// Select the largest directories
+		for dirPath, stat := range extStats {
+			top.offer(FileStat{Path: dirPath, Size: stat.Size, DiskSize: stat.DiskSize})
+		}
+
+		fileCount = int64(len(extStats))
+		extStats = make(map[string]ExtStat)
+	}
+
+	// Smallest first, displayed in reverse
+	topFiles := slashPaths(top.sorted())
+
+	categoryStats := make(map[string]CategoryStat, len(categories))
+	for category, stat := range categories {
+		categoryStats[category] = CategoryStat{ExtStat: stat, TopFiles: []FileStat{}}
+	}
+
+	for category, top := range catTop {
+		categoryStats[category] = CategoryStat{ExtStat: categories[category], TopFiles: slashPaths(top.sorted())}
+	}
+
+	extHistograms := make(map[string][]SizeBucket, len(extHist))
+	for ext, hist := range extHist {
+		extHistograms[ext] = hist.buckets()
+	}
+
+	var tree *TreeNode
+	if c.tree {
+		tree = c.buildTree()
+	}
+
+	return &Stats{
+		FileCount:      fileCount,
+		TotalBytes:     totalBytes,
+		TotalDiskBytes: totalDisk,
+		ExtStats:       extStats,
+		TopFiles:       topFiles,
+		ErrorCount:     c.errorCount,
+		Errors:         c.errors,
+		ErrorClasses:   c.errorClasses,
+		HardLinks:      c.hardLinks,
+		HardLinkBytes:  c.hardLinkBytes,
+		FollowedLinks:  c.followed,
+		LinkDuplicates: c.linkDupes,
+		LinkCycles:     c.linkCycles,
+		MountPoints:    c.mountPoints,
+		DirectoryMode:  c.directoryMode,
+		TopN:           c.topN,
+		DiskUsage:      c.diskUsage,
+		TimeField:      c.timeField,
+		AgeBuckets:     newAgeBuckets(ageBuckets),
+		SizeHistogram:  sizeHist.buckets(),
+		ExtHistograms:  extHistograms,
+		Hidden:         hidden,
+		ContentTypes:   types,
+		Mismatches:     c.mismatches,
+		MismatchCount:  c.mismatchCount,
+		Categories:     categoryStats,
+		Empties:        c.emptyStats(),
+		EntryTypes:     entryTypes,
+		Owners:         newOwnerStats(users, groups),
+		GroupBy:        c.groupBy,
+		Tree:           tree,
+	}
+}
+
+// slashPaths converts the paths of files to slash format for display.
+func slashPaths(files []FileStat) []FileStat {
+	for i := range files {
+		files[i].Path = filepath.ToSlash(files[i].Path)
+		// Remove leading "./" prefix
+		files[i].Path = strings.TrimPrefix(files[i].Path, "./")
+	}
+
+	return files
+}
@@ -0,0 +1,133 @@
+package dirstat
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxEmpties is the default number of empty files and directories listed in Stats.Empties.
+const DefaultMaxEmpties = 1000
+
+// EmptyStats lists the empty files and the directories without any analyzed content.
+//
+//nolint:tagliatelle // Using snake_case for JSON compatibility
+type EmptyStats struct {
+	// FileCount is the number of zero-byte files.
+	FileCount int64 `json:"file_count"`
+	// DirCount is the number of directories that contain no analyzed entries.
+	DirCount int64 `json:"dir_count"`
+	// Files lists the zero-byte files, capped at Options.MaxEmpties.
+	Files []string `json:"files"`
+	// Dirs lists the empty directories, deepest first, capped at Options.MaxEmpties.
+	Dirs []string `json:"dirs"`
+}
+
+// Truncated reports whether more empty files or directories were found than listed.
+func (e EmptyStats) Truncated() bool {
+	return int64(len(e.Files)) < e.FileCount || int64(len(e.Dirs)) < e.DirCount
+}
+
+// dirState tracks whether a visited directory has content.
+type dirState struct {
+	rel     string
+	display string
+	used    bool
+}
+
+// visitDir records the directory at rel (slash path relative to the scan root) as a candidate
+// empty directory, unless content was already found below it.
+func (c *collector) visitDir(rel, display string) {
+	s := c.shardFor(rel)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the mark if content was already found
+	state := s.dirs[rel]
+	state.rel = rel
+	state.display = display
+	s.dirs[rel] = state
+}
+
+// markUsed records that the directory at rel and all of its ancestors have content.
+// It stops at the first ancestor that was already marked.
+func (c *collector) markUsed(rel string) {
+	if !c.empties {
+		return
+	}
+
+	for {
+		s := c.shardFor(rel)
+
+		s.mu.Lock()
+		state := s.dirs[rel]
+		done := state.used
+		state.used = true
+		s.dirs[rel] = state
+		s.mu.Unlock()
+
+		if done || rel == "" {
+			return
+		}
+
+		rel = parentDir(rel)
+	}
+}
+
+// addEmptyFile records a zero-byte file.
+func (c *collector) addEmptyFile(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.emptyFiles++
+
+	if c.maxEmpties == 0 || len(c.emptyPaths) < c.maxEmpties {
+		c.emptyPaths = append(c.emptyPaths, strings.TrimPrefix(filepath.ToSlash(path), "./"))
+	}
+}
+
+// emptyStats collects the empty directories from the shards.
+// The caller must hold the collector mutex.
+func (c *collector) emptyStats() EmptyStats {
+	stats := EmptyStats{FileCount: c.emptyFiles, Files: c.emptyPaths, Dirs: []string{}}
+
+	sort.Strings(stats.Files)
+
+	var dirs []dirState
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+
+		for _, state := range s.dirs {
+			// Directories that were only marked were never visited, the scan root is never reported
+			if !state.used && state.display != "" && state.rel != "" {
+				dirs = append(dirs, state)
+			}
+		}
+
+		s.mu.Unlock()
+	}
+
+	// Deepest first, so the list can be removed in order
+	sort.Slice(dirs, func(i, j int) bool {
+		depthI, depthJ := strings.Count(dirs[i].rel, "/"), strings.Count(dirs[j].rel, "/")
+		if depthI != depthJ {
+			return depthI > depthJ
+		}
+
+		return dirs[i].rel < dirs[j].rel
+	})
+
+	stats.DirCount = int64(len(dirs))
+
+	if c.maxEmpties > 0 && len(dirs) > c.maxEmpties {
+		dirs = dirs[:c.maxEmpties]
+	}
+
+	for _, state := range dirs {
+		stats.Dirs = append(stats.Dirs, strings.TrimPrefix(filepath.ToSlash(state.display), "./"))
+	}
+
+	return stats
+}
@@ -0,0 +1,47 @@
+package dirstat
+
+import (
+	"io/fs"
+)
+
+// Entry types counted in Stats.EntryTypes.
+const (
+	EntryTypeRegular = "regular"
+	EntryTypeDir     = "dir"
+	EntryTypeSymlink = "symlink"
+	EntryTypeOther   = "other"
+)
+
+// entryType returns the entry type of mode.
+func entryType(mode fs.FileMode) string {
+	switch {
+	case mode.IsRegular():
+		return EntryTypeRegular
+	case mode.IsDir():
+		return EntryTypeDir
+	case mode&fs.ModeSymlink != 0:
+		return EntryTypeSymlink
+	default:
+		return EntryTypeOther
+	}
+}
+
+// addEntryType counts the entry at path by its type. Sizes are recorded for regular files,
+// directories (the size of the directory itself) and symlinks (the length of the target),
+// but not for devices, sockets and FIFOs. Entries that cannot be read are counted without a size.
+func (c *collector) addEntryType(path string, d fs.DirEntry) { //nolint:varnamelen // d is standard for DirEntry
+	kind := entryType(d.Type())
+
+	stat := entry{}
+	if info, err := d.Info(); err == nil && kind != EntryTypeOther {
+		stat.size = info.Size()
+		stat.diskSize = allocatedSize(info)
+	}
+
+	s := c.shardFor(path)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entryTypes[kind] = s.entryTypes[kind].with(stat)
+}
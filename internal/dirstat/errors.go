@@ -0,0 +1,67 @@
+package dirstat
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// DefaultMaxErrors is the default number of walk errors kept in Stats.Errors.
+const DefaultMaxErrors = 100
+
+// Error classes used to categorize walk errors.
+const (
+	// ErrorClassPermission indicates that access to a path was denied.
+	ErrorClassPermission = "permission denied"
+	// ErrorClassVanished indicates that a path disappeared during the walk.
+	ErrorClassVanished = "vanished"
+	// ErrorClassIO indicates a low-level I/O error.
+	ErrorClassIO = "i/o error"
+	// ErrorClassOther covers all remaining errors.
+	ErrorClassOther = "other"
+)
+
+// WalkError describes a path that could not be read during the walk.
+type WalkError struct {
+	// Path is the path that could not be read.
+	Path string `json:"path"`
+	// Op is the operation that failed (e.g. open, lstat).
+	Op string `json:"op"`
+	// Class is the error class (e.g. permission denied, vanished).
+	Class string `json:"class"`
+	// Message is the underlying error message.
+	Message string `json:"message"`
+}
+
+// newWalkError creates a WalkError for path from err.
+func newWalkError(path string, err error) WalkError {
+	op := "walk"
+
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		op = pathErr.Op
+	}
+
+	return WalkError{
+		Path:    strings.TrimPrefix(filepath.ToSlash(path), "./"),
+		Op:      op,
+		Class:   classifyError(err),
+		Message: err.Error(),
+	}
+}
+
+// classifyError maps err to one of the error classes.
+func classifyError(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return ErrorClassPermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrorClassVanished
+	case errors.Is(err, syscall.EIO):
+		return ErrorClassIO
+	default:
+		return ErrorClassOther
+	}
+}
@@ -0,0 +1,110 @@
+package dirstat
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultCompoundExtensions are the multi-part extensions recognized by default.
+//
+//nolint:gochecknoglobals // Default configuration
+var DefaultCompoundExtensions = []string{".tar.gz", ".tar.bz2", ".tar.xz", ".tar.zst", ".d.ts"}
+
+// extNormalizer computes the extension used for aggregation and filtering.
+// Compound extensions take precedence over the last suffix, case is optionally
+// folded and aliases map extensions onto a common one.
+type extNormalizer struct {
+	compounds []string
+	foldCase  bool
+	aliases   map[string]string
+}
+
+// newExtNormalizer creates a normalizer for the given compound extensions and aliases.
+func newExtNormalizer(compounds []string, foldCase bool, aliases map[string]string) *extNormalizer {
+	normalizer := &extNormalizer{
+		compounds: make([]string, 0, len(compounds)),
+		foldCase:  foldCase,
+		aliases:   make(map[string]string, len(aliases)),
+	}
+
+	for _, compound := range compounds {
+		normalizer.compounds = append(normalizer.compounds, normalizer.fold(compound))
+	}
+
+	// Prefer the longest compound, e.g. ".tar.gz" over ".gz"
+	sort.Slice(normalizer.compounds, func(i, j int) bool {
+		return len(normalizer.compounds[i]) > len(normalizer.compounds[j])
+	})
+
+	for from, to := range aliases {
+		normalizer.aliases[normalizer.fold(from)] = normalizer.fold(to)
+	}
+
+	return normalizer
+}
+
+// fold lowercases value if case folding is enabled.
+func (n *extNormalizer) fold(value string) string {
+	if n.foldCase {
+		return strings.ToLower(value)
+	}
+
+	return value
+}
+
+// of returns the normalized extension of the file at path.
+func (n *extNormalizer) of(path string) string {
+	name := n.fold(filepath.Base(path))
+	ext := filepath.Ext(name)
+
+	for _, compound := range n.compounds {
+		if len(name) > len(compound) && strings.HasSuffix(name, compound) {
+			ext = compound
+
+			break
+		}
+	}
+
+	return n.normalize(ext)
+}
+
+// normalize applies case folding and aliases to ext.
+func (n *extNormalizer) normalize(ext string) string {
+	ext = n.fold(ext)
+
+	if alias, ok := n.aliases[ext]; ok {
+		return alias
+	}
+
+	return ext
+}
+
+// matches reports whether the --ext filter value applies to the file at path with
+// the normalized extension ext. A single extension or a known compound extension matches
+// the normalized extension exactly, the key files are aggregated under, so ".gz" does not
+// match ".tar.gz". Other values match as a suffix of the path (e.g. "_test.go", ".pb.go").
+func (n *extNormalizer) matches(path, ext, value string) bool {
+	if n.known(value) {
+		return ext == n.normalize(value)
+	}
+
+	return strings.HasSuffix(n.fold(path), n.fold(value))
+}
+
+// known reports whether value is an extension files can be aggregated under:
+// a single extension, a compound extension or an alias.
+func (n *extNormalizer) known(value string) bool {
+	if !strings.HasPrefix(value, ".") {
+		return false
+	}
+
+	value = n.fold(value)
+
+	if _, ok := n.aliases[value]; ok || filepath.Ext(value) == value {
+		return true
+	}
+
+	return slices.Contains(n.compounds, value)
+}
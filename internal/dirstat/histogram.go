@@ -0,0 +1,103 @@
+package dirstat
+
+import (
+	"cmp"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// sizeBuckets is the number of log2 size buckets; bucket 0 holds empty files
+// and bucket k holds sizes in [2^(k-1), 2^k).
+const sizeBuckets = 64
+
+// SizeBucket holds the number and total size of files within a size range.
+type SizeBucket struct {
+	// Min is the inclusive lower bound in bytes.
+	Min int64 `json:"min"`
+	// Max is the exclusive upper bound in bytes.
+	Max int64 `json:"max"`
+	// Count is the number of files in the range.
+	Count int64 `json:"count"`
+	// Size is the cumulative size in bytes.
+	Size int64 `json:"size"`
+}
+
+// sizeHistogram counts files in log2 size buckets. Only non-empty buckets are stored,
+// ordered by index, as a histogram is kept for every extension of every shard.
+type sizeHistogram []histBucket
+
+// histBucket is a non-empty bucket of a sizeHistogram.
+type histBucket struct {
+	index int
+	count int64
+	size  int64
+}
+
+// sizeBucket returns the bucket index for size.
+func sizeBucket(size int64) int {
+	if size <= 0 {
+		return 0
+	}
+
+	return bits.Len64(uint64(size))
+}
+
+// add records a file of the given size.
+func (h *sizeHistogram) add(size int64) {
+	h.addBucket(histBucket{index: sizeBucket(size), count: 1, size: size})
+}
+
+// addBucket adds the counts of bucket to the bucket with the same index.
+func (h *sizeHistogram) addBucket(bucket histBucket) {
+	i, found := slices.BinarySearchFunc(*h, bucket.index, func(existing histBucket, index int) int {
+		return cmp.Compare(existing.index, index)
+	})
+	if !found {
+		*h = slices.Insert(*h, i, histBucket{index: bucket.index})
+	}
+
+	(*h)[i].count += bucket.count
+	(*h)[i].size += bucket.size
+}
+
+// merge adds the counts of other to h.
+func (h *sizeHistogram) merge(other sizeHistogram) {
+	for _, bucket := range other {
+		h.addBucket(bucket)
+	}
+}
+
+// buckets returns the contiguous range of buckets between the smallest
+// and the largest non-empty bucket.
+func (h *sizeHistogram) buckets() []SizeBucket {
+	if len(*h) == 0 {
+		return []SizeBucket{}
+	}
+
+	first, last := (*h)[0].index, (*h)[len(*h)-1].index
+	buckets := make([]SizeBucket, 0, last-first+1)
+	stored := *h
+
+	for i := first; i <= last; i++ {
+		bucket := SizeBucket{Max: 1}
+
+		if stored[0].index == i {
+			bucket.Count, bucket.Size = stored[0].count, stored[0].size
+			stored = stored[1:]
+		}
+
+		if i > 0 {
+			bucket.Min = int64(1) << (i - 1)
+			bucket.Max = math.MaxInt64
+
+			if i < sizeBuckets-1 {
+				bucket.Max = int64(1) << i
+			}
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
@@ -0,0 +1,450 @@
+package dirstat
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignoreFiles are the per-directory ignore files, in increasing order of precedence.
+//
+//nolint:gochecknoglobals // Fixed list of file names
+var ignoreFiles = []string{".gitignore", ".ignore"}
+
+// ignoreRule is a single compiled gitignore pattern.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ruleSet holds the rules of one ignore file.
+// Paths are matched relative to the directory containing the file: base is stripped
+// from paths below the scan root, prefix is prepended for files above the scan root.
+type ruleSet struct {
+	base   string
+	prefix string
+	rules  []ignoreRule
+}
+
+// ignorer evaluates .gitignore, .ignore, .git/info/exclude and the global excludes file
+// hierarchically. Rules are loaded lazily per directory and cached, since fastwalk
+// visits directories concurrently and in no particular order.
+type ignorer struct {
+	mu      sync.Mutex
+	root    string
+	rules   map[string][]*ruleSet
+	ignored map[string]struct{}
+}
+
+// newIgnorer creates an ignorer for the scan root. absRoot is the absolute form of root,
+// used to find the enclosing repository and the ignore files above the scan root.
+func newIgnorer(root, absRoot string) *ignorer {
+	repoRoot := findRepoRoot(absRoot)
+	if repoRoot == "" {
+		repoRoot = absRoot
+	}
+
+	// Path of the scan root relative to the repository root
+	prefix := ""
+
+	if rel, err := filepath.Rel(repoRoot, absRoot); err == nil && rel != "." {
+		prefix = filepath.ToSlash(rel) + "/"
+	}
+
+	sets := make([]*ruleSet, 0)
+	sets = appendRuleSet(sets, globalExcludesFile(), "", prefix)
+
+	// Ignore files between the repository root and the scan root
+	if prefix != "" {
+		sets = appendRuleSet(sets, filepath.Join(repoRoot, ".git", "info", "exclude"), "", prefix)
+
+		dir := repoRoot
+		parts := strings.Split(strings.TrimSuffix(prefix, "/"), "/")
+
+		for i := range parts {
+			for _, name := range ignoreFiles {
+				sets = appendRuleSet(sets, filepath.Join(dir, name), "", strings.Join(parts[i:], "/")+"/")
+			}
+
+			dir = filepath.Join(dir, parts[i])
+		}
+	}
+
+	ig := &ignorer{
+		root:    root,
+		rules:   make(map[string][]*ruleSet),
+		ignored: make(map[string]struct{}),
+	}
+
+	ig.rules[""] = append(sets, ig.load("")...)
+
+	return ig
+}
+
+// isIgnored reports whether the entry at rel (slash path relative to the scan root) is ignored.
+// Entries below an ignored directory are always ignored.
+func (ig *ignorer) isIgnored(rel string, isDir bool) bool {
+	parent := parentDir(rel)
+
+	ig.mu.Lock()
+	_, parentIgnored := ig.ignored[parent]
+	ig.mu.Unlock()
+
+	ignored := parentIgnored || ig.match(rel, isDir, ig.rulesFor(parent))
+
+	if ignored && isDir {
+		ig.mu.Lock()
+		ig.ignored[rel] = struct{}{}
+		ig.mu.Unlock()
+	}
+
+	return ignored
+}
+
+// match evaluates the rule sets in order of precedence; the last matching rule wins.
+func (ig *ignorer) match(rel string, isDir bool, sets []*ruleSet) bool {
+	ignored := false
+
+	for _, set := range sets {
+		candidate := rel
+
+		if set.base != "" {
+			candidate = strings.TrimPrefix(rel, set.base+"/")
+		}
+
+		candidate = set.prefix + candidate
+
+		for _, rule := range set.rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+
+			if rule.re.MatchString(candidate) {
+				ignored = !rule.negate
+			}
+		}
+	}
+
+	return ignored
+}
+
+// rulesFor returns the rule sets applying to entries of dir, loading them if necessary.
+func (ig *ignorer) rulesFor(dir string) []*ruleSet {
+	ig.mu.Lock()
+	sets, ok := ig.rules[dir]
+	ig.mu.Unlock()
+
+	if ok {
+		return sets
+	}
+
+	inherited := ig.rulesFor(parentDir(dir))
+	sets = append(inherited[:len(inherited):len(inherited)], ig.load(dir)...)
+
+	ig.mu.Lock()
+	ig.rules[dir] = sets
+	ig.mu.Unlock()
+
+	return sets
+}
+
+// load reads the ignore files located in dir.
+func (ig *ignorer) load(dir string) []*ruleSet {
+	fsDir := filepath.Join(ig.root, filepath.FromSlash(dir))
+	sets := make([]*ruleSet, 0)
+
+	if info, err := os.Stat(filepath.Join(fsDir, ".git")); err == nil && info.IsDir() {
+		sets = appendRuleSet(sets, filepath.Join(fsDir, ".git", "info", "exclude"), dir, "")
+	}
+
+	for _, name := range ignoreFiles {
+		sets = appendRuleSet(sets, filepath.Join(fsDir, name), dir, "")
+	}
+
+	return sets
+}
+
+// appendRuleSet parses the ignore file at file and appends it to sets if it has any rules.
+func appendRuleSet(sets []*ruleSet, file, base, prefix string) []*ruleSet {
+	if file == "" {
+		return sets
+	}
+
+	handle, err := os.Open(file)
+	if err != nil {
+		return sets
+	}
+	defer handle.Close()
+
+	set := &ruleSet{base: base, prefix: prefix}
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			set.rules = append(set.rules, rule)
+		}
+	}
+
+	if len(set.rules) == 0 {
+		return sets
+	}
+
+	return append(sets, set)
+}
+
+// parseIgnoreLine compiles a line of an ignore file using gitwildmatch semantics.
+// It returns false for blank lines, comments and invalid patterns.
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+
+	switch {
+	case strings.HasPrefix(line, "!"):
+		rule.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Patterns with a slash are anchored to the directory of the ignore file,
+	// others match at any level.
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	var expr strings.Builder
+
+	expr.WriteString("^")
+
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	expr.WriteString(globToRegex(line))
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+
+	rule.re = re
+
+	return rule, true
+}
+
+// globToRegex translates a gitwildmatch glob into a regular expression.
+//
+//nolint:gocognit,cyclop // Straightforward character-by-character translation.
+func globToRegex(glob string) string {
+	var expr strings.Builder
+
+	runes := []rune(glob)
+
+	for i := 0; i < len(runes); i++ {
+		switch char := runes[i]; char {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atStart := i == 0 || runes[i-1] == '/'
+				atEnd := i+2 == len(runes) || runes[i+2] == '/'
+
+				switch {
+				case atStart && i+2 == len(runes):
+					// Trailing "**" matches everything inside
+					expr.WriteString(".*")
+				case atStart && atEnd:
+					// "**/" matches zero or more directories
+					expr.WriteString("(?:.*/)?")
+
+					i++
+				default:
+					// Any other "**" behaves like "*"
+					expr.WriteString("[^/]*")
+				}
+
+				i++
+
+				continue
+			}
+
+			expr.WriteString("[^/]*")
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+
+			if end >= len(runes) {
+				expr.WriteString(`\[`)
+
+				continue
+			}
+
+			class := runes[i+1 : end]
+
+			expr.WriteString("[")
+
+			if class[0] == '!' || class[0] == '^' {
+				expr.WriteString("^/")
+
+				class = class[1:]
+			}
+
+			for _, member := range class {
+				if member == '\\' || member == '[' || member == ']' || member == '^' {
+					expr.WriteRune('\\')
+				}
+
+				expr.WriteRune(member)
+			}
+
+			expr.WriteString("]")
+
+			i = end
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+
+	return expr.String()
+}
+
+// parentDir returns the parent of the slash path rel, using "" for the scan root.
+func parentDir(rel string) string {
+	parent := path.Dir(rel)
+	if parent == "." || parent == "/" {
+		return ""
+	}
+
+	return parent
+}
+
+// findRepoRoot returns the closest directory at or above dir containing a .git entry,
+// or an empty string if there is none.
+func findRepoRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+// globalExcludesFile returns the path of git's global excludes file.
+// It honors core.excludesFile from the user's git configuration and
+// falls back to $XDG_CONFIG_HOME/git/ignore.
+func globalExcludesFile() string {
+	home, _ := os.UserHomeDir()
+
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+
+	file := ""
+
+	if configHome != "" {
+		file = filepath.Join(configHome, "git", "ignore")
+	}
+
+	// Later configuration files take precedence
+	configs := []string{}
+
+	if configHome != "" {
+		configs = append(configs, filepath.Join(configHome, "git", "config"))
+	}
+
+	if home != "" {
+		configs = append(configs, filepath.Join(home, ".gitconfig"))
+	}
+
+	for _, config := range configs {
+		if value := readExcludesFile(config); value != "" {
+			file = value
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(file, "~/"); ok && home != "" {
+		file = filepath.Join(home, rest)
+	}
+
+	return file
+}
+
+// readExcludesFile extracts core.excludesFile from the git configuration file at config.
+func readExcludesFile(config string) string {
+	handle, err := os.Open(config)
+	if err != nil {
+		return ""
+	}
+	defer handle.Close()
+
+	var (
+		section string
+		value   string
+	)
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+
+			continue
+		}
+
+		key, val, found := strings.Cut(line, "=")
+		if !found || section != "core" || !strings.EqualFold(strings.TrimSpace(key), "excludesfile") {
+			continue
+		}
+
+		value = strings.Trim(strings.TrimSpace(val), `"`)
+	}
+
+	return value
+}
@@ -0,0 +1,21 @@
+//go:build linux
+
+package dirstat
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// openNoAtime opens the file at path for reading without updating its access time,
+// so sniffing does not skew atime-based filters. O_NOATIME is only permitted for the
+// owner of the file, other files are opened normally.
+func openNoAtime(path string) (*os.File, error) {
+	handle, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0)
+	if errors.Is(err, syscall.EPERM) {
+		return os.Open(path)
+	}
+
+	return handle, err
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package dirstat
+
+import (
+	"os"
+)
+
+// openNoAtime opens the file at path for reading. Reading may update the access time,
+// since the platform offers no way to prevent it.
+func openNoAtime(path string) (*os.File, error) {
+	return os.Open(path)
+}
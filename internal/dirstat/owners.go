@@ -0,0 +1,159 @@
+package dirstat
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Breakdowns shown in the summary.
+const (
+	GroupByExtension = "extension"
+	GroupByOwner     = "owner"
+	GroupByCategory  = "category"
+)
+
+// Local account databases used to resolve user and group names.
+const (
+	passwdFile = "/etc/passwd"
+	groupFile  = "/etc/group"
+)
+
+// OwnerStats holds statistics aggregated by owning user and group.
+type OwnerStats struct {
+	// Users maps user names (or numeric IDs if unknown) to their statistics.
+	Users map[string]ExtStat `json:"users"`
+	// Groups maps group names (or numeric IDs if unknown) to their statistics.
+	Groups map[string]ExtStat `json:"groups"`
+}
+
+// collectsOwners reports whether statistics are aggregated per owner and group,
+// which is only done for the owner breakdown and when filtering by owner or group.
+func (o Options) collectsOwners() bool {
+	return o.GroupBy == GroupByOwner || len(o.Owners) > 0 || len(o.Groups) > 0
+}
+
+// newOwnerStats resolves the collected user and group IDs to names.
+func newOwnerStats(users, groups map[uint32]ExtStat) OwnerStats {
+	owners := OwnerStats{
+		Users:  make(map[string]ExtStat, len(users)),
+		Groups: make(map[string]ExtStat, len(groups)),
+	}
+
+	if len(users) == 0 && len(groups) == 0 {
+		return owners
+	}
+
+	userNames := readIDNames(passwdFile)
+	groupNames := readIDNames(groupFile)
+
+	for uid, stat := range users {
+		owners.Users[idName(userNames, uid)] = stat
+	}
+
+	for gid, stat := range groups {
+		owners.Groups[idName(groupNames, gid)] = stat
+	}
+
+	return owners
+}
+
+// idName returns the name of id, or its decimal form if the name is unknown.
+func idName(names map[uint32]string, id uint32) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// readIDNames parses a passwd or group file into a map of IDs to names.
+// Both formats store the name in the first and the ID in the third field.
+func readIDNames(file string) map[uint32]string {
+	names := make(map[uint32]string)
+
+	handle, err := os.Open(file)
+	if err != nil {
+		return names
+	}
+	defer handle.Close()
+
+	scanner := bufio.NewScanner(handle)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 { //nolint:mnd // Name, password and ID fields
+			continue
+		}
+
+		id, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			continue
+		}
+
+		// Keep the first entry for duplicate IDs
+		if _, ok := names[uint32(id)]; !ok {
+			names[uint32(id)] = fields[0]
+		}
+	}
+
+	return names
+}
+
+// resolveIDs converts user or group names and numeric IDs to a set of IDs,
+// looking up names in file.
+func resolveIDs(values []string, file string) (map[uint32]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil //nolint:nilnil // No filter
+	}
+
+	ids := make(map[uint32]struct{}, len(values))
+
+	var byName map[string]uint32
+
+	for _, value := range values {
+		if id, err := strconv.ParseUint(value, 10, 32); err == nil {
+			ids[uint32(id)] = struct{}{}
+
+			continue
+		}
+
+		if byName == nil {
+			byName = make(map[string]uint32)
+			for id, name := range readIDNames(file) {
+				byName[name] = id
+			}
+		}
+
+		id, ok := byName[value]
+		if !ok {
+			return nil, fmt.Errorf("unknown name %q in %s", value, file)
+		}
+
+		ids[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// matchesID reports whether id passes the filter ids. Files without owner
+// information only pass an empty filter.
+func matchesID(ids map[uint32]struct{}, id uint32, known bool) bool {
+	if ids == nil {
+		return true
+	}
+
+	if !known {
+		return false
+	}
+
+	_, ok := ids[id]
+
+	return ok
+}
@@ -0,0 +1,97 @@
+package dirstat
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// regexPrefix marks a path pattern as a regular expression instead of a glob.
+const regexPrefix = "re:"
+
+// pathPattern is a compiled include or exclude pattern.
+//
+// Globs support "**" and are anchored to the scan root; a trailing "/" restricts
+// them to directories. Patterns prefixed with "re:" are regular expressions matched
+// against the full slash-separated path.
+type pathPattern struct {
+	source  string
+	re      *regexp.Regexp
+	isRegex bool
+	dirOnly bool
+}
+
+// compilePattern compiles a glob or "re:"-prefixed regex pattern.
+// If contents is set, directory-only globs match the entries inside the directory
+// instead of the directory itself.
+func compilePattern(pattern string, contents bool) (pathPattern, error) {
+	compiled := pathPattern{source: pattern}
+
+	if expr, ok := strings.CutPrefix(pattern, regexPrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return pathPattern{}, fmt.Errorf("compiling regex %q: %w", expr, err)
+		}
+
+		compiled.re = re
+		compiled.isRegex = true
+
+		return compiled, nil
+	}
+
+	glob := strings.TrimPrefix(pattern, "/")
+
+	if strings.HasSuffix(glob, "/") {
+		compiled.dirOnly = !contents
+		glob = strings.TrimRight(glob, "/")
+	}
+
+	if glob == "" {
+		return pathPattern{}, fmt.Errorf("empty glob %q", pattern)
+	}
+
+	expr := "^" + globToRegex(glob)
+
+	if contents && strings.HasSuffix(pattern, "/") {
+		expr += "/.*"
+	}
+
+	re, err := regexp.Compile(expr + "$")
+	if err != nil {
+		return pathPattern{}, fmt.Errorf("compiling glob %q: %w", pattern, err)
+	}
+
+	compiled.re = re
+
+	return compiled, nil
+}
+
+// compilePatterns compiles all patterns, see compilePattern.
+func compilePatterns(patterns []string, contents bool) ([]pathPattern, error) {
+	compiled := make([]pathPattern, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		pat, err := compilePattern(pattern, contents)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled = append(compiled, pat)
+	}
+
+	return compiled, nil
+}
+
+// match reports whether the entry matches. path is the slash-separated walk path,
+// rel the slash-separated path relative to the scan root.
+func (p pathPattern) match(path, rel string, isDir bool) bool {
+	if p.isRegex {
+		return p.re.MatchString(path)
+	}
+
+	if rel == "" || (p.dirOnly && !isDir) {
+		return false
+	}
+
+	return p.re.MatchString(rel)
+}
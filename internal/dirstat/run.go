@@ -6,8 +6,8 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charlievieth/fastwalk"
@@ -41,29 +41,45 @@ func calculateDepth(path, root string) int {
 	return strings.Count(relPath, string(filepath.Separator)) + 1
 }
 
-// shouldExcludeByPattern checks if path matches any exclusion regex.
-func shouldExcludeByPattern(path string, patterns []*regexp.Regexp) *regexp.Regexp {
+// matchingPattern returns the first pattern matching the entry, or nil if there is none.
+// path is the walk path and rel the slash-separated path relative to the scan root.
+func matchingPattern(path, rel string, isDir bool, patterns []pathPattern) *pathPattern {
 	if len(patterns) == 0 {
 		return nil
 	}
 
 	fPath := filepath.ToSlash(path)
 
-	for _, re := range patterns {
-		if re.MatchString(fPath) {
-			return re
+	for i := range patterns {
+		if patterns[i].match(fPath, rel, isDir) {
+			return &patterns[i]
 		}
 	}
 
 	return nil
 }
 
+// relativePath returns path relative to root in slash format, or "" for root itself.
+func relativePath(path, root string) string {
+	if path == root {
+		return ""
+	}
+
+	return strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(path, root)), "/")
+}
+
+// isHidden reports whether the slash path rel (relative to the scan root)
+// is a dotfile or lies below a dot-directory.
+func isHidden(rel string) bool {
+	return strings.HasPrefix(rel, ".") || strings.Contains(rel, "/.")
+}
+
 // shouldIncludeByExtension checks if file should be included based on extension filters.
 // Returns true if file should be included, false if excluded.
-func shouldIncludeByExtension(path string, include, exclude map[string]struct{}) bool {
+func shouldIncludeByExtension(path, ext string, exts *extNormalizer, include, exclude map[string]struct{}) bool {
 	// Check excludes first
-	for ext := range exclude {
-		if strings.HasSuffix(path, ext) {
+	for value := range exclude {
+		if exts.matches(path, ext, value) {
 			return false
 		}
 	}
@@ -72,8 +88,8 @@ func shouldIncludeByExtension(path string, include, exclude map[string]struct{})
 		return true
 	}
 	// Check includes
-	for ext := range include {
-		if strings.HasSuffix(path, ext) {
+	for value := range include {
+		if exts.matches(path, ext, value) {
 			return true
 		}
 	}
@@ -81,6 +97,69 @@ func shouldIncludeByExtension(path string, include, exclude map[string]struct{})
 	return false
 }
 
+// displayPath makes path relative to cwd, or absolute if the scan target is outside cwd.
+func displayPath(path, cwd string, outsideCwd bool) string {
+	if outsideCwd {
+		// Outside cwd: use absolute paths
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return path
+		}
+
+		return absPath
+	}
+
+	// Inside cwd: use paths relative to cwd
+	relPath, err := filepath.Rel(cwd, path)
+	if err != nil {
+		return path
+	}
+
+	return relPath
+}
+
+// rollupDirs returns dir and its ancestors whose depth relative to root lies
+// between 1 and maxDepth. Directories at the root level return root itself.
+func rollupDirs(dir, root string, maxDepth int) []string {
+	depth := calculateDepth(dir, root)
+	if depth == 0 {
+		return []string{dir}
+	}
+
+	dirs := make([]string, 0, min(depth, maxDepth))
+
+	for ; depth >= 1; depth-- {
+		if depth <= maxDepth {
+			dirs = append(dirs, dir)
+		}
+
+		dir = filepath.Dir(dir)
+	}
+
+	return dirs
+}
+
+// isAncestor reports whether target refers to one of the parent directories of path.
+func isAncestor(path string, target fs.FileInfo) bool {
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+
+		parentInfo, err := os.Stat(parent)
+		if err != nil {
+			return false
+		}
+
+		if os.SameFile(target, parentInfo) {
+			return true
+		}
+
+		path = parent
+	}
+}
+
 // startProgressReporter invokes hook(files, bytes) on each tick until ctx is done.
 //
 //nolint:varnamelen // c is idiomatic for collector
@@ -101,12 +180,7 @@ func startProgressReporter(ctx context.Context, c *collector, hook func(int64, i
 		for {
 			select {
 			case <-ticker.C:
-				c.mu.Lock()
-
-				files := c.fileCount
-				bytes := c.totalBytes
-				c.mu.Unlock()
-				hook(files, bytes)
+				hook(c.progress())
 			case <-ctx.Done():
 				return
 			}
@@ -115,14 +189,37 @@ func startProgressReporter(ctx context.Context, c *collector, hook func(int64, i
 }
 
 // Run performs directory analysis and returns aggregated statistics.
-// It walks the directory tree at opt.Path, filters files based on opt.Extensions
-// and opt.Excludes, and collects statistics about file sizes and extensions.
+// It walks the directory tree at opt.Path, filters files based on opt.Extensions,
+// opt.Includes and opt.Excludes, and collects statistics about file sizes and extensions.
 //
 // If opt.DirsMode is true, it aggregates statistics by directory instead of
-// individual files. If opt.Depth > 0, it limits traversal to the specified depth.
+// individual files. If opt.GroupDepth > 0, each file is credited to all of its
+// ancestors up to that depth, yielding subtree totals.
+// If opt.Depth > 0, it limits traversal to the specified depth.
+// Hard-linked files are counted once per inode unless opt.CountLinks is set.
+// If opt.Follow is true, symlinks are followed; cycles and repeated targets are skipped.
+// If opt.OneFileSystem is true, directories on other filesystems are pruned.
+// If opt.GitIgnore is true, entries ignored by .gitignore and .ignore files are pruned;
+// opt.IgnoredOnly instead restricts the analysis to those ignored entries.
+// If opt.NoHidden is true, dotfiles are skipped and dot-directories pruned;
+// opt.HiddenOnly instead restricts the analysis to them.
+// If opt.Sniff is true, files are also classified by content on a bounded pool of readers;
+// opt.Mismatches additionally lists files whose content disagrees with their extension.
+// If opt.Empties is true, zero-byte files and directories without any analyzed
+// entries are listed in Stats.Empties.
+// If opt.Where is set, only files satisfying the filter expression are analyzed.
+// If opt.Owners or opt.Groups are set, only files owned by those users or groups are analyzed.
+// Statistics per owner and group are only collected with those filters or if opt.GroupBy is "owner".
+// Extensions are normalized using opt.CompoundExtensions, opt.FoldCase and opt.ExtAliases,
+// both for opt.Extensions and for the keys of Stats.ExtStats.
+// If opt.DiskUsage is true, allocated sizes drive sorting instead of apparent sizes.
+// If opt.Tree is true, the directory hierarchy with the largest files per directory
+// is built in Stats.Tree.
 //
-// The walk operation can be cancelled via ctx. Progress updates are sent
-// to progressHook if provided.
+// The walk operation can be cancelled via ctx, in which case the statistics
+// collected so far are returned with Stats.Interrupted set. Progress updates
+// are sent to progressHook if provided, and every accepted file is passed
+// to opt.EntryHook if set.
 //
 //nolint:gocognit,funlen,gocyclo,cyclop,maintidx // TODO(Idelchi): Simplify function.
 func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*Stats, error) {
@@ -151,11 +248,20 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 	outsideCwd := err != nil || strings.HasPrefix(relToTarget, "..")
 
 	// validate path exists and is accessible
-	if statInfo, err := os.Stat(opt.Path); err != nil {
+	rootInfo, err := os.Stat(opt.Path)
+	if err != nil {
 		return nil, fmt.Errorf("accessing path %q: %w", opt.Path, err)
-	} else if !statInfo.IsDir() {
+	} else if !rootInfo.IsDir() {
 		return nil, fmt.Errorf("path %q is not a directory", opt.Path)
 	}
+
+	// Remember the root device to detect filesystem boundaries
+	rootKey, _, _ := fileIdentity(rootInfo)
+
+	exts := newExtNormalizer(opt.CompoundExtensions, opt.FoldCase, opt.ExtAliases)
+
+	categories := newCategorizer(opt.Categories, exts)
+
 	// setup extension set for quick lookup
 	extInclude := make(map[string]struct{}, len(opt.Extensions))
 
@@ -175,7 +281,29 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 		opt.TopN = 20
 	}
 
-	collector := newCollector(opt.TopN, opt.DirsMode)
+	if opt.MaxErrors <= 0 {
+		opt.MaxErrors = DefaultMaxErrors
+	}
+
+	if opt.TimeField == "" {
+		opt.TimeField = TimeFieldMtime
+	}
+
+	if opt.GroupBy == "" {
+		opt.GroupBy = GroupByExtension
+	}
+
+	ownerFilter, err := resolveIDs(opt.Owners, passwdFile)
+	if err != nil {
+		return nil, fmt.Errorf("resolving owner filter: %w", err)
+	}
+
+	groupFilter, err := resolveIDs(opt.Groups, groupFile)
+	if err != nil {
+		return nil, fmt.Errorf("resolving group filter: %w", err)
+	}
+
+	collector := newCollector(opt)
 
 	// Create child context to ensure progress reporter cleanup
 	ctx, cancel := context.WithCancel(ctx)
@@ -184,15 +312,14 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 	// Start progress reporter goroutine
 	startProgressReporter(ctx, collector, progressHook, opt.ProgressInterval)
 
-	excludeRegexes := make([]*regexp.Regexp, 0, len(opt.Excludes))
-
-	for _, p := range opt.Excludes {
-		re, err := regexp.Compile(p)
-		if err != nil {
-			return nil, fmt.Errorf("compiling exclusion pattern %q: %w", p, err)
-		}
+	excludePatterns, err := compilePatterns(opt.Excludes, false)
+	if err != nil {
+		return nil, fmt.Errorf("compiling exclusion pattern: %w", err)
+	}
 
-		excludeRegexes = append(excludeRegexes, re)
+	includePatterns, err := compilePatterns(opt.Includes, true)
+	if err != nil {
+		return nil, fmt.Errorf("compiling inclusion pattern: %w", err)
 	}
 
 	log.printf("\n")
@@ -208,17 +335,52 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 		log.printf("[debug]:   - %s\n", ext)
 	}
 
-	log.printf("[debug]: exclude regexes:\n")
+	log.printf("[debug]: exclude patterns:\n")
 
-	for _, re := range excludeRegexes {
-		log.printf("[debug]:   - %s\n", re.String())
+	for _, pat := range excludePatterns {
+		log.printf("[debug]:   - %s\n", pat.source)
+	}
+
+	log.printf("[debug]: include patterns:\n")
+
+	for _, pat := range includePatterns {
+		log.printf("[debug]:   - %s\n", pat.source)
 	}
 
 	start := time.Now()
 
+	var where *Where
+
+	if opt.Where != "" {
+		where, err = ParseWhere(opt.Where, start)
+		if err != nil {
+			return nil, fmt.Errorf("parsing where expression: %w", err)
+		}
+
+		log.printf("[debug]: where expression: %s\n", where)
+	}
+
+	// Ownership is only looked up if it is aggregated or filtered on
+	lookupOwners := opt.collectsOwners() || (where != nil && where.usesOwners())
+
+	var ignore *ignorer
+
+	if opt.GitIgnore || opt.IgnoredOnly {
+		ignore = newIgnorer(opt.Path, absTargetPath)
+	}
+
+	var sniff *sniffer
+
+	if opt.Sniff || opt.Mismatches {
+		sniff = startSniffer(ctx, collector, opt.Mismatches)
+	}
+
+	// Serializes calls to opt.EntryHook
+	var hookMu sync.Mutex
+
 	// Configure fastwalk
 	conf := &fastwalk.Config{
-		Follow: false, // Don't follow symlinks
+		Follow: false, // Symlinks are followed manually to detect cycles and duplicate targets
 	}
 
 	// Walk directory with fastwalk (parallel traversal)
@@ -226,8 +388,10 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 	walkErr := fastwalk.Walk(conf, opt.Path, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			log.printf("[debug]: error accessing path %s: %v\n", path, err)
+			collector.addError(path, err)
+			collector.markUsed(relativePath(path, opt.Path))
 
-			return nil // Silently skip errors
+			return nil // Skip unreadable paths, they are reported in the stats
 		}
 
 		// Check cancellation periodically
@@ -237,9 +401,17 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 		default:
 		}
 
+		// Count every visited entry before any prune or filter decision
+		collector.addEntryType(path, d)
+
+		rel := relativePath(path, opt.Path)
+
 		// Calculate current depth and check against limit
 		currentDepth := calculateDepth(path, opt.Path)
 		if opt.Depth > 0 && currentDepth > opt.Depth {
+			// Entries beyond the depth limit are not scanned, but their directory is not empty
+			collector.markUsed(parentDir(rel))
+
 			if d.IsDir() {
 				log.printf("[debug]: skipping directory (beyond depth %d): %s\n", opt.Depth, path)
 
@@ -251,112 +423,349 @@ func Run(ctx context.Context, opt Options, progressHook func(int64, int64)) (*St
 			return nil
 		}
 
-		// Check regex exclusion patterns
-		if matchedPattern := shouldExcludeByPattern(path, excludeRegexes); matchedPattern != nil {
+		// Check exclusion patterns
+		if matchedPattern := matchingPattern(path, rel, d.IsDir(), excludePatterns); matchedPattern != nil {
 			fPath := filepath.ToSlash(path)
 
 			if d.IsDir() {
 				log.printf("[debug]: excluding directory: %s\n", fPath)
-				log.printf("	 matched regex: %s\n", matchedPattern.String())
+				log.printf("	 matched pattern: %s\n", matchedPattern.source)
 
 				return filepath.SkipDir
 			}
 
 			log.printf("[debug]: excluding file: %s\n", fPath)
-			log.printf("	 matched regex: %s\n", matchedPattern.String())
+			log.printf("	 matched pattern: %s\n", matchedPattern.source)
+
+			return nil
+		}
+
+		// Apply ignore files
+		if ignore != nil && rel != "" {
+			// The repository metadata is never part of the work tree
+			if d.IsDir() && d.Name() == ".git" {
+				log.printf("[debug]: skipping git directory: %s\n", path)
+
+				return filepath.SkipDir
+			}
+
+			ignored := ignore.isIgnored(rel, d.IsDir())
+
+			switch {
+			case ignored && !opt.IgnoredOnly && d.IsDir():
+				log.printf("[debug]: skipping directory (ignored): %s\n", path)
+
+				return filepath.SkipDir
+			case ignored && !opt.IgnoredOnly:
+				log.printf("[debug]: skipping file (ignored): %s\n", path)
+
+				return nil
+			case !ignored && opt.IgnoredOnly && !d.IsDir():
+				log.printf("[debug]: skipping file (not ignored): %s\n", path)
+
+				return nil
+			}
+		}
+
+		// Apply hidden path filters
+		hidden := isHidden(rel)
+
+		if opt.NoHidden && hidden {
+			if d.IsDir() {
+				log.printf("[debug]: skipping directory (hidden): %s\n", path)
+
+				return filepath.SkipDir
+			}
+
+			log.printf("[debug]: skipping file (hidden): %s\n", path)
+
+			return nil
+		}
+
+		if opt.HiddenOnly && !hidden && !d.IsDir() {
+			log.printf("[debug]: skipping file (not hidden): %s\n", path)
 
 			return nil
 		}
 
 		if d.IsDir() {
+			if opt.Empties {
+				collector.visitDir(rel, displayPath(path, cwd, outsideCwd))
+			}
+
+			if !opt.Follow && !opt.OneFileSystem {
+				return nil
+			}
+
+			dirInfo, err := d.Info()
+			if err != nil {
+				collector.addError(path, err)
+				collector.markUsed(rel)
+
+				return nil //nolint:nilerr // Intentionally skip errors during walk
+			}
+
+			key, _, ok := fileIdentity(dirInfo)
+			if !ok {
+				return nil
+			}
+
+			// Prune mount points when staying on one filesystem
+			if opt.OneFileSystem && key.dev != rootKey.dev {
+				log.printf("[debug]: skipping directory (other filesystem): %s\n", path)
+				collector.addMountPoint(path)
+				collector.markUsed(rel)
+
+				return filepath.SkipDir
+			}
+
+			// Skip directories that were already visited through a followed symlink
+			if opt.Follow && !collector.claimInode(key) {
+				log.printf("[debug]: skipping directory (already visited): %s\n", path)
+				collector.markUsed(rel)
+
+				return filepath.SkipDir
+			}
+
 			return nil
 		}
 
+		isLink := d.Type()&fs.ModeSymlink != 0
+
 		// Process file directly (no channel, no workers)
-		if !d.Type().IsRegular() {
+		if !d.Type().IsRegular() && (!isLink || !opt.Follow) {
+			// Directories holding symlinks or special files are never reported as empty
+			collector.markUsed(parentDir(rel))
+
 			return nil
 		}
 
 		fileInfo, err := d.Info()
 		if err != nil {
-			collector.addError()
+			collector.addError(path, err)
+			collector.markUsed(parentDir(rel))
 
 			return nil //nolint:nilerr // Intentionally skip errors during walk
 		}
 
-		if fileInfo.Size() < opt.MinSize {
+		if isLink {
+			collector.markUsed(parentDir(rel))
+
+			target, err := fastwalk.StatDirEntry(path, d)
+			if err != nil {
+				log.printf("[debug]: skipping broken symlink: %s\n", path)
+
+				return nil //nolint:nilerr // Intentionally skip broken symlinks
+			}
+
+			if target.IsDir() {
+				if isAncestor(path, target) {
+					log.printf("[debug]: skipping symlink (cycle): %s\n", path)
+					collector.addLinkCycle(path)
+
+					return nil
+				}
+
+				key, _, ok := fileIdentity(target)
+				if ok && opt.OneFileSystem && key.dev != rootKey.dev {
+					log.printf("[debug]: skipping symlink (other filesystem): %s\n", path)
+					collector.addMountPoint(path)
+
+					return nil
+				}
+
+				if ok && !collector.claimInode(key) {
+					log.printf("[debug]: skipping symlink (target already visited): %s\n", path)
+					collector.addLinkDuplicate()
+
+					return nil
+				}
+
+				log.printf("[debug]: following symlink: %s\n", path)
+				collector.addFollowedLink()
+
+				return fastwalk.ErrTraverseLink
+			}
+
+			if !target.Mode().IsRegular() {
+				return nil
+			}
+
+			fileInfo = target
+		}
+
+		if fileInfo.Size() < opt.MinSize || (opt.MaxSize > 0 && fileInfo.Size() > opt.MaxSize) {
+			return nil
+		}
+
+		// Check age filters
+		age := start.Sub(fileTime(fileInfo, opt.TimeField))
+		if (opt.OlderThan > 0 && age < opt.OlderThan) || (opt.NewerThan > 0 && age > opt.NewerThan) {
+			log.printf("[debug]: excluding file (age filter): %s\n", path)
+
+			return nil
+		}
+
+		// Check inclusion patterns
+		if len(includePatterns) > 0 && matchingPattern(path, rel, false, includePatterns) == nil {
+			log.printf("[debug]: excluding file (no inclusion pattern matched): %s\n", path)
+
 			return nil
 		}
 
 		// Check extension filters
-		if !shouldIncludeByExtension(path, extInclude, extExclude) {
+		ext := exts.of(path)
+		if !shouldIncludeByExtension(path, ext, exts, extInclude, extExclude) {
 			log.printf("[debug]: excluding file (extension filter): %s\n", path)
 
 			return nil
 		}
 
+		// Check owner filters
+		var (
+			uid, gid uint32
+			hasOwner bool
+		)
+
+		if lookupOwners {
+			uid, gid, hasOwner = fileOwner(fileInfo)
+		}
+
+		if !matchesID(ownerFilter, uid, hasOwner) || !matchesID(groupFilter, gid, hasOwner) {
+			log.printf("[debug]: excluding file (owner filter): %s\n", path)
+
+			return nil
+		}
+
+		// Check filter expression
+		if where != nil {
+			kind := EntryTypeFile
+			if isLink {
+				kind = EntryTypeSymlink
+			}
+
+			candidate := whereEntry{
+				rel:      rel,
+				ext:      ext,
+				kind:     kind,
+				depth:    currentDepth,
+				info:     fileInfo,
+				uid:      uid,
+				gid:      gid,
+				hasOwner: hasOwner,
+			}
+
+			if !where.matches(&candidate) {
+				log.printf("[debug]: excluding file (where expression): %s\n", path)
+
+				return nil
+			}
+		}
+
+		// Count each inode only once. Symlink targets are always deduplicated,
+		// hard links only unless they are explicitly counted per path.
+		// Only accepted files claim their inode, so a rejected link does not hide a matching one.
+		if key, nlink, ok := fileIdentity(fileInfo); ok && (opt.Follow || (!opt.CountLinks && nlink > 1)) &&
+			!collector.claimInode(key) {
+			collector.markUsed(parentDir(rel))
+
+			switch {
+			case isLink || nlink == 1:
+				log.printf("[debug]: skipping file (already counted through symlink): %s\n", path)
+				collector.addLinkDuplicate()
+
+				return nil
+			case !opt.CountLinks:
+				log.printf("[debug]: skipping hard link (already counted): %s\n", path)
+				collector.addHardLink(fileInfo.Size())
+
+				return nil
+			}
+		}
+
+		if isLink {
+			collector.addFollowedLink()
+		}
+
+		file := entry{
+			path:     displayPath(path, cwd, outsideCwd),
+			ext:      ext,
+			size:     fileInfo.Size(),
+			diskSize: allocatedSize(fileInfo),
+			age:      age,
+			uid:      uid,
+			gid:      gid,
+			hasOwner: hasOwner,
+			hidden:   hidden,
+			category: categories.of(path, ext),
+		}
+
+		collector.markUsed(parentDir(rel))
+
+		if opt.Empties && file.size == 0 {
+			collector.addEmptyFile(file.path)
+		}
+
 		// Update collector
-		if opt.DirsMode { //nolint:nestif	// Nesting needed for relative/absolute handling
+		if opt.DirsMode {
 			// Aggregate by directory (use directory of file, not file itself)
-			dirPath := filepath.Dir(path)
-
-			// Make path relative to cwd or absolute if outside cwd
-			var displayPath string
-
-			if outsideCwd {
-				// Outside cwd: use absolute paths
-				absDir, absErr := filepath.Abs(dirPath)
-				if absErr == nil {
-					displayPath = absDir
-				} else {
-					displayPath = dirPath
-				}
-			} else {
-				// Inside cwd: use paths relative to cwd
-				relDir, err := filepath.Rel(cwd, dirPath)
-				if err != nil {
-					displayPath = dirPath
-				} else {
-					displayPath = relDir
-				}
+			dirs := []string{filepath.Dir(path)}
+
+			// Roll up into all ancestors up to the aggregation depth
+			if opt.GroupDepth > 0 {
+				dirs = rollupDirs(dirs[0], opt.Path, opt.GroupDepth)
 			}
 
-			collector.add(displayPath, fileInfo.Size(), "DIR:")
-		} else {
-			// Make path relative to cwd or absolute if outside cwd
-			var displayPath string
-
-			if outsideCwd {
-				// Outside cwd: use absolute paths
-				absPath, absErr := filepath.Abs(path)
-				if absErr == nil {
-					displayPath = absPath
-				} else {
-					displayPath = path
-				}
-			} else {
-				// Inside cwd: use paths relative to cwd
-				relPath, err := filepath.Rel(cwd, path)
-				if err != nil {
-					displayPath = path
-				} else {
-					displayPath = relPath
-				}
+			for i, dir := range dirs {
+				dirs[i] = displayPath(dir, cwd, outsideCwd)
 			}
 
-			ext := filepath.Ext(path)
-			collector.add(displayPath, fileInfo.Size(), ext)
+			collector.addRollup(dirs, file)
+		} else {
+			collector.add(file)
+		}
+
+		if opt.Tree {
+			collector.addTree(parentDir(rel), file)
+		}
+
+		if sniff != nil {
+			sniff.submit(path, file)
+		}
+
+		if opt.EntryHook != nil {
+			hookMu.Lock()
+			opt.EntryHook(Entry{
+				Path:    strings.TrimPrefix(filepath.ToSlash(file.path), "./"),
+				Size:    file.size,
+				Ext:     file.ext,
+				ModTime: fileInfo.ModTime(),
+				Depth:   currentDepth,
+			})
+			hookMu.Unlock()
 		}
 
 		return nil
 	})
-	if walkErr != nil {
+
+	if sniff != nil {
+		sniff.wait()
+	}
+
+	// A cancelled walk still yields the statistics collected so far
+	interrupted := walkErr != nil && ctx.Err() != nil
+	if walkErr != nil && !interrupted {
 		return nil, walkErr
 	}
 
 	stats := collector.finalize()
 
+	if stats.Tree != nil {
+		stats.Tree.Name = filepath.ToSlash(displayPath(opt.Path, cwd, outsideCwd))
+	}
+
 	stats.Elapsed = time.Since(start)
+	stats.Interrupted = interrupted
 
 	return stats, nil
 }
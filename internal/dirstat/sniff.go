@@ -0,0 +1,249 @@
+package dirstat
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Content types detected by sniffing.
+const (
+	ContentELF     = "elf"
+	ContentPE      = "pe"
+	ContentGzip    = "gzip"
+	ContentZstd    = "zstd"
+	ContentZip     = "zip"
+	ContentPNG     = "png"
+	ContentJPEG    = "jpeg"
+	ContentPDF     = "pdf"
+	ContentSQLite  = "sqlite"
+	ContentText    = "text"
+	ContentBinary  = "binary"
+	ContentEmpty   = "empty"
+	ContentUnknown = "unknown"
+)
+
+const (
+	// sniffLen is the number of leading bytes read to classify a file.
+	sniffLen = 512
+	// sniffWorkers is the number of goroutines reading file headers.
+	// It is kept small so content reads do not compete with the metadata walk.
+	sniffWorkers = 4
+	// sniffQueue is the number of files waiting to be sniffed before the walk blocks.
+	sniffQueue = 1024
+	// maxMismatches is the maximum number of mismatches kept in Stats.Mismatches.
+	maxMismatches = 100
+)
+
+// magic is a signature identifying a content type.
+type magic struct {
+	content string
+	prefix  []byte
+}
+
+// magics lists the recognized signatures.
+//
+//nolint:gochecknoglobals // Fixed lookup table
+var magics = []magic{
+	{ContentELF, []byte("\x7fELF")},
+	{ContentPE, []byte("MZ")},
+	{ContentGzip, []byte{0x1f, 0x8b}},
+	{ContentZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{ContentZip, []byte("PK\x03\x04")},
+	{ContentZip, []byte("PK\x05\x06")},
+	{ContentZip, []byte("PK\x07\x08")},
+	{ContentPNG, []byte("\x89PNG\r\n\x1a\n")},
+	{ContentJPEG, []byte{0xff, 0xd8, 0xff}},
+	{ContentPDF, []byte("%PDF-")},
+	{ContentSQLite, []byte("SQLite format 3\x00")},
+}
+
+// expectedContent maps file extensions to the content type they imply.
+// Extensions that are not listed are never reported as mismatches, including ambiguous
+// ones such as .ts (TypeScript or MPEG transport stream).
+//
+//nolint:gochecknoglobals // Fixed lookup table
+var expectedContent = map[string]string{
+	".so":      ContentELF,
+	".exe":     ContentPE,
+	".dll":     ContentPE,
+	".gz":      ContentGzip,
+	".tgz":     ContentGzip,
+	".tar.gz":  ContentGzip,
+	".zst":     ContentZstd,
+	".tar.zst": ContentZstd,
+	".zip":     ContentZip,
+	".jar":     ContentZip,
+	".whl":     ContentZip,
+	".apk":     ContentZip,
+	".docx":    ContentZip,
+	".xlsx":    ContentZip,
+	".pptx":    ContentZip,
+	".png":     ContentPNG,
+	".jpg":     ContentJPEG,
+	".jpeg":    ContentJPEG,
+	".pdf":     ContentPDF,
+	".sqlite":  ContentSQLite,
+	".sqlite3": ContentSQLite,
+	".txt":     ContentText,
+	".md":      ContentText,
+	".go":      ContentText,
+	".py":      ContentText,
+	".js":      ContentText,
+	".d.ts":    ContentText,
+	".c":       ContentText,
+	".h":       ContentText,
+	".sh":      ContentText,
+	".json":    ContentText,
+	".yml":     ContentText,
+	".yaml":    ContentText,
+	".toml":    ContentText,
+	".xml":     ContentText,
+	".html":    ContentText,
+	".css":     ContentText,
+	".csv":     ContentText,
+	".log":     ContentText,
+}
+
+// Mismatch describes a file whose content disagrees with its extension.
+type Mismatch struct {
+	// Path is the file path.
+	Path string `json:"path"`
+	// Ext is the file extension.
+	Ext string `json:"ext"`
+	// Expected is the content type implied by the extension.
+	Expected string `json:"expected"`
+	// Detected is the content type found by sniffing.
+	Detected string `json:"detected"`
+}
+
+// sniffContent classifies the leading bytes of a file.
+func sniffContent(head []byte) string {
+	if len(head) == 0 {
+		return ContentEmpty
+	}
+
+	for _, sig := range magics {
+		if bytes.HasPrefix(head, sig.prefix) {
+			return sig.content
+		}
+	}
+
+	if bytes.IndexByte(head, 0) >= 0 {
+		return ContentBinary
+	}
+
+	// Ignore a multi-byte character cut off at the end of the buffer
+	if len(head) == sniffLen {
+		for i := 1; i < utf8.UTFMax && i < len(head); i++ {
+			if utf8.RuneStart(head[len(head)-i]) {
+				if !utf8.FullRune(head[len(head)-i:]) {
+					head = head[:len(head)-i]
+				}
+
+				break
+			}
+		}
+	}
+
+	if utf8.Valid(head) {
+		return ContentText
+	}
+
+	return ContentBinary
+}
+
+// sniffFile reads the leading bytes of the file at path and classifies them.
+func sniffFile(path string) (string, error) {
+	handle, err := openNoAtime(path)
+	if err != nil {
+		return "", err
+	}
+	defer handle.Close()
+
+	head := make([]byte, sniffLen)
+
+	n, err := io.ReadFull(handle, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	content := sniffContent(head[:n])
+
+	// A DOS stub alone is not enough, require the PE header it points to if it was read
+	if content == ContentPE && n >= 0x40 {
+		// Bounds are checked unsigned, a large offset must not wrap to a negative int on 32-bit platforms
+		offset := uint64(binary.LittleEndian.Uint32(head[0x3c:]))
+		if offset+4 <= uint64(n) && !bytes.Equal(head[offset:offset+4], []byte("PE\x00\x00")) {
+			content = ContentBinary
+		}
+	}
+
+	return content, nil
+}
+
+// sniffJob is a file waiting to be classified.
+type sniffJob struct {
+	path string
+	file entry
+}
+
+// sniffer classifies files by content on a bounded pool of workers,
+// separate from the fastwalk callbacks.
+type sniffer struct {
+	jobs chan sniffJob
+	wg   sync.WaitGroup
+}
+
+// startSniffer starts the sniffing workers, which record their results in c.
+// Mismatches between content and extension are listed if mismatches is set.
+// Pending files are skipped once ctx is cancelled.
+func startSniffer(ctx context.Context, c *collector, mismatches bool) *sniffer {
+	s := &sniffer{jobs: make(chan sniffJob, sniffQueue)}
+
+	for range sniffWorkers {
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			for job := range s.jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				content, err := sniffFile(job.path)
+				if err != nil {
+					c.addError(job.path, err)
+
+					content = ContentUnknown
+				}
+
+				c.addContentType(job.file, content)
+
+				expected, ok := expectedContent[strings.ToLower(job.file.ext)]
+				if mismatches && ok && content != expected && content != ContentEmpty && content != ContentUnknown {
+					c.addMismatch(Mismatch{Path: job.file.path, Ext: job.file.ext, Expected: expected, Detected: content})
+				}
+			}
+		}()
+	}
+
+	return s
+}
+
+// submit queues the file at path for classification, blocking while the queue is full.
+func (s *sniffer) submit(path string, file entry) {
+	s.jobs <- sniffJob{path: path, file: file}
+}
+
+// wait stops accepting files and waits for the queued ones to be classified.
+func (s *sniffer) wait() {
+	close(s.jobs)
+	s.wg.Wait()
+}
@@ -1,10 +1,6 @@
 package dirstat
 
 import (
-	"path/filepath"
-	"sort"
-	"strings"
-	"sync"
 	"time"
 )
 
@@ -14,6 +10,17 @@ type ExtStat struct {
 	Count int `json:"count"`
 	// Size is the cumulative size in bytes.
 	Size int64 `json:"size"`
+	// DiskSize is the cumulative allocated size on disk in bytes.
+	DiskSize int64 `json:"disk_size"`
+}
+
+// Bytes returns the allocated size if diskUsage is set, otherwise the apparent size.
+func (e ExtStat) Bytes(diskUsage bool) int64 {
+	if diskUsage {
+		return e.DiskSize
+	}
+
+	return e.Size
 }
 
 // FileStat represents a single file path and size.
@@ -22,6 +29,31 @@ type FileStat struct {
 	Path string `json:"path"`
 	// Size is the size in bytes.
 	Size int64 `json:"size"`
+	// DiskSize is the allocated size on disk in bytes.
+	DiskSize int64 `json:"disk_size"`
+}
+
+// Bytes returns the allocated size if diskUsage is set, otherwise the apparent size.
+func (f FileStat) Bytes(diskUsage bool) int64 {
+	if diskUsage {
+		return f.DiskSize
+	}
+
+	return f.Size
+}
+
+// Entry describes a file accepted by the walk, as passed to Options.EntryHook.
+type Entry struct {
+	// Path is the display path of the file.
+	Path string `json:"path"`
+	// Size is the apparent size in bytes.
+	Size int64 `json:"size"`
+	// Ext is the normalized file extension.
+	Ext string `json:"ext"`
+	// ModTime is the modification time.
+	ModTime time.Time `json:"mtime"`
+	// Depth is the depth below the scan root (1 = files in the scan root).
+	Depth int `json:"depth"`
 }
 
 // Stats holds aggregate statistics for a directory walk.
@@ -32,18 +64,80 @@ type Stats struct {
 	FileCount int64 `json:"file_count"`
 	// TotalBytes is the cumulative size of all analyzed files.
 	TotalBytes int64 `json:"total_bytes"`
+	// TotalDiskBytes is the cumulative allocated size of all analyzed files.
+	TotalDiskBytes int64 `json:"total_disk_bytes"`
 	// ExtStats maps file extensions to their statistics.
 	ExtStats map[string]ExtStat `json:"ext_stats"`
 	// TopFiles contains the N largest files or directories.
 	TopFiles []FileStat `json:"top_files"`
 	// ErrorCount is the number of errors encountered.
 	ErrorCount int64 `json:"error_count"`
+	// Errors lists the paths that could not be read, capped at Options.MaxErrors.
+	Errors []WalkError `json:"errors"`
+	// ErrorClasses maps error classes to the number of errors of that class.
+	ErrorClasses map[string]int64 `json:"error_classes"`
+	// HardLinks is the number of hard-linked duplicates that were not counted again.
+	HardLinks int64 `json:"hard_links"`
+	// HardLinkBytes is the size the skipped hard-linked duplicates would have added.
+	HardLinkBytes int64 `json:"hard_link_bytes"`
+	// FollowedLinks is the number of symlinks that were followed.
+	FollowedLinks int64 `json:"followed_links"`
+	// LinkDuplicates is the number of followed symlinks skipped because their target was already counted.
+	LinkDuplicates int64 `json:"link_duplicates"`
+	// LinkCycles lists the symlinks that were not followed because they lead back to an ancestor.
+	LinkCycles []string `json:"link_cycles"`
+	// MountPoints lists the directories that were pruned because they are on another filesystem.
+	MountPoints []string `json:"mount_points"`
 	// Elapsed is the total time taken for analysis.
 	Elapsed time.Duration `json:"elapsed"`
+	// Interrupted indicates that the walk was cancelled and the statistics are incomplete.
+	Interrupted bool `json:"interrupted"`
 	// DirectoryMode indicates whether analyzing directories instead of files.
 	DirectoryMode bool `json:"directory_mode"`
 	// TopN is the number of top results tracked.
 	TopN int `json:"top_n"`
+	// DiskUsage indicates whether allocated sizes drive sorting and percentages.
+	DiskUsage bool `json:"disk_usage"`
+	// TimeField is the timestamp used for age filters and buckets (mtime, atime or ctime).
+	TimeField string `json:"time_field"`
+	// AgeBuckets breaks down the analyzed files by age.
+	AgeBuckets []AgeBucket `json:"age_buckets"`
+	// SizeHistogram breaks down the analyzed files into log2 size buckets.
+	SizeHistogram []SizeBucket `json:"size_histogram"`
+	// ExtHistograms maps file extensions to their log2 size buckets.
+	ExtHistograms map[string][]SizeBucket `json:"ext_histograms"`
+	// Hidden holds the statistics of files below hidden paths (dotfiles and dot-directories).
+	Hidden ExtStat `json:"hidden"`
+	// ContentTypes maps content types detected by sniffing to their statistics.
+	ContentTypes map[string]ExtStat `json:"content_types"`
+	// Mismatches lists files whose content disagrees with their extension, capped at 100.
+	Mismatches []Mismatch `json:"mismatches"`
+	// MismatchCount is the total number of files whose content disagrees with their extension.
+	MismatchCount int64 `json:"mismatch_count"`
+	// Categories maps categories (source, media, archives, ...) to their statistics and largest files.
+	Categories map[string]CategoryStat `json:"categories"`
+	// Empties lists the empty files and directories if Options.Empties is set.
+	Empties EmptyStats `json:"empties"`
+	// EntryTypes counts every entry visited by the walk by type (regular, dir, symlink, other), before
+	// any filter applies. Entries inside pruned directories are not visited.
+	// Sizes are directory sizes for dir and target path lengths for symlink.
+	EntryTypes map[string]ExtStat `json:"entry_types"`
+	// Owners breaks down the analyzed files by owning user and group if Options.GroupBy is "owner"
+	// or an owner or group filter is set.
+	Owners OwnerStats `json:"owners"`
+	// GroupBy is the breakdown shown in the summary (extension, owner or category).
+	GroupBy string `json:"group_by"`
+	// Tree is the hierarchy of directories and their largest files if Options.Tree is set.
+	Tree *TreeNode `json:"tree,omitempty"`
+}
+
+// Total returns the total allocated size if DiskUsage is set, otherwise the total apparent size.
+func (s *Stats) Total() int64 {
+	if s.DiskUsage {
+		return s.TotalDiskBytes
+	}
+
+	return s.TotalBytes
 }
 
 // Options configures directory analysis and CLI behavior.
@@ -52,169 +146,93 @@ type Options struct {
 	Path string
 	// Extensions to include (empty = all).
 	Extensions []string
-	// Excludes contains regex patterns to exclude.
+	// CompoundExtensions are multi-part extensions such as ".tar.gz" (nil = none).
+	CompoundExtensions []string
+	// FoldCase indicates whether extensions are compared and aggregated case-insensitively.
+	FoldCase bool
+	// ExtAliases maps extensions onto the extension they are aggregated as (e.g. ".jpeg" to ".jpg").
+	ExtAliases map[string]string
+	// Includes contains glob or "re:"-prefixed regex patterns a file must match (empty = all).
+	Includes []string
+	// Excludes contains glob or "re:"-prefixed regex patterns to exclude.
 	Excludes []string
+	// GitIgnore indicates whether to skip entries ignored by .gitignore and .ignore files.
+	GitIgnore bool
+	// IgnoredOnly indicates whether to analyze only entries ignored by .gitignore and .ignore files.
+	IgnoredOnly bool
+	// NoHidden indicates whether to skip hidden files and prune hidden directories.
+	NoHidden bool
+	// HiddenOnly indicates whether to analyze only files below hidden paths.
+	HiddenOnly bool
 	// MinSize is the minimum file size in bytes.
 	MinSize int64
+	// MaxSize is the maximum file size in bytes (0=unlimited).
+	MaxSize int64
+	// OlderThan skips files younger than this age (0=disabled).
+	OlderThan time.Duration
+	// NewerThan skips files older than this age (0=disabled).
+	NewerThan time.Duration
+	// TimeField selects the timestamp for age filters and buckets (mtime, atime or ctime).
+	TimeField string
+	// Owners contains user names or IDs a file must be owned by (empty = all).
+	Owners []string
+	// Groups contains group names or IDs a file must belong to (empty = all).
+	Groups []string
+	// Sniff indicates whether files are classified by their content.
+	Sniff bool
+	// Mismatches indicates whether files whose content disagrees with their extension are listed.
+	Mismatches bool
+	// Empties indicates whether empty files and directories are listed.
+	Empties bool
+	// MaxEmpties is the maximum number of empty files and directories listed each (0=unlimited).
+	MaxEmpties int
+	// Print0 indicates whether to print only the empty paths, NUL-terminated.
+	Print0 bool
+	// Where is a filter expression files must satisfy (empty = all), see ParseWhere.
+	Where string
+	// Categories adds to or overrides DefaultCategories, mapping categories to extensions and name globs.
+	Categories map[string][]string
+	// GroupBy selects the breakdown shown in the summary (extension, owner or category).
+	GroupBy string
 	// TopN is the number of top results to track.
 	TopN int
 	// Depth is the maximum traversal depth (0=unlimited).
 	Depth int
 	// DirsMode indicates whether to aggregate by directory instead of files.
 	DirsMode bool
+	// GroupDepth rolls file sizes up into all ancestor directories up to this depth (0=direct parent only).
+	GroupDepth int
+	// DiskUsage indicates whether allocated sizes are used instead of apparent sizes.
+	DiskUsage bool
+	// Follow indicates whether to follow symbolic links.
+	Follow bool
+	// OneFileSystem indicates whether to skip directories on other filesystems.
+	OneFileSystem bool
+	// CountLinks indicates whether hard-linked files are counted once per path instead of once per inode.
+	CountLinks bool
+	// MaxErrors is the maximum number of walk errors kept in Stats.Errors.
+	MaxErrors int
+	// Strict indicates whether unreadable paths should cause a failure.
+	Strict bool
+	// EntryHook is called for every accepted file while the walk runs.
+	// Calls are serialized, but come from the walk goroutines, so the hook should return quickly.
+	EntryHook func(Entry)
 	// ProgressInterval controls progress callback cadence.
 	ProgressInterval time.Duration
 	// Debug indicates whether debug output is enabled.
 	Debug bool
-	// Output represents output format (table or json).
+	// Output represents output format (table, json, ndjson, csv, tsv, markdown or html).
 	Output string
+	// Format is a Go template executed against Stats instead of the output format (empty = unused).
+	Format string
+	// Records selects the record set of delimited output (files, extensions or dirs).
+	Records string
+	// Tree builds the directory hierarchy in Stats.Tree.
+	Tree bool
+	// Collapse wraps markdown lists longer than this many rows in a collapsible block (0=never).
+	Collapse int
 	// Version indicates whether to show version and exit.
 	Version bool
 	// Integration indicates whether to output integration script.
 	Integration bool
 }
-
-// collector aggregates statistics from concurrent fastwalk callbacks using a mutex.
-type collector struct {
-	mu            sync.Mutex // Protect concurrent access
-	topN          int
-	directoryMode bool
-	extStats      map[string]ExtStat
-	topFiles      []FileStat
-	fileCount     int64
-	totalBytes    int64
-	errorCount    int64
-}
-
-// newCollector creates a collector with the requested configuration.
-func newCollector(topN int, directoryMode bool) *collector {
-	return &collector{
-		topN:          topN,
-		directoryMode: directoryMode,
-		extStats:      make(map[string]ExtStat),
-		topFiles:      make([]FileStat, 0),
-	}
-}
-
-// addError increments the error counter. This operation is protected by a mutex
-// since fastwalk calls the callback from multiple goroutines concurrently.
-func (c *collector) addError() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.errorCount++
-}
-
-// add records a file or directory. This operation is protected by a mutex
-// since fastwalk calls the callback from multiple goroutines concurrently.
-//
-// In directory mode (ext == "DIR:"), path is a directory and we accumulate sizes.
-// Otherwise path is a file.
-func (c *collector) add(path string, size int64, ext string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.totalBytes += size
-
-	isDirectoryMode := ext == "DIR:"
-
-	if isDirectoryMode {
-		stat := c.extStats[path]
-		if stat.Count == 0 {
-			c.fileCount++
-		}
-
-		stat.Count++
-
-		stat.Size += size
-
-		c.extStats[path] = stat
-	} else {
-		c.fileCount++
-
-		stat := c.extStats[ext]
-		stat.Count++
-
-		stat.Size += size
-
-		c.extStats[ext] = stat
-
-		// Collect all files, we'll sort and trim later
-		c.topFiles = append(c.topFiles, FileStat{Path: path, Size: size})
-	}
-}
-
-// finalize produces the final Stats from the collected data.
-// It extracts the top N files or directories by size and converts paths
-// to slash format for cross-platform consistency.
-func (c *collector) finalize() *Stats {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var (
-		extStats  map[string]ExtStat
-		topFiles  []FileStat
-		fileCount int64
-	)
-
-	if c.directoryMode {
-		// Build slice of directories by size
-		topFiles = make([]FileStat, 0, len(c.extStats))
-		for dirPath, stat := range c.extStats {
-			topFiles = append(topFiles, FileStat{Path: dirPath, Size: stat.Size})
-		}
-
-		// Sort by size (largest first) and trim to top N
-		sort.Slice(topFiles, func(i, j int) bool {
-			return topFiles[i].Size > topFiles[j].Size
-		})
-
-		if len(topFiles) > c.topN {
-			topFiles = topFiles[:c.topN]
-		}
-
-		// Reverse for display (smallest first, displayed in reverse)
-		for i, j := 0, len(topFiles)-1; i < j; i, j = i+1, j-1 {
-			topFiles[i], topFiles[j] = topFiles[j], topFiles[i]
-		}
-
-		extStats = make(map[string]ExtStat)
-		fileCount = int64(len(c.extStats))
-	} else {
-		extStats = c.extStats
-
-		// Sort by size (largest first) and trim to top N
-		sort.Slice(c.topFiles, func(i, j int) bool {
-			return c.topFiles[i].Size > c.topFiles[j].Size
-		})
-
-		if len(c.topFiles) > c.topN {
-			c.topFiles = c.topFiles[:c.topN]
-		}
-
-		// Reverse for display (smallest first, displayed in reverse)
-		topFiles = make([]FileStat, len(c.topFiles))
-		for i := range c.topFiles {
-			topFiles[i] = c.topFiles[len(c.topFiles)-1-i]
-		}
-
-		fileCount = c.fileCount
-	}
-
-	// Convert all paths to slash format for display
-	for i := range topFiles {
-		topFiles[i].Path = filepath.ToSlash(topFiles[i].Path)
-		// Remove leading "./" prefix
-		topFiles[i].Path = strings.TrimPrefix(topFiles[i].Path, "./")
-	}
-
-	return &Stats{
-		FileCount:     fileCount,
-		TotalBytes:    c.totalBytes,
-		ExtStats:      extStats,
-		TopFiles:      topFiles,
-		ErrorCount:    c.errorCount,
-		DirectoryMode: c.directoryMode,
-		TopN:          c.topN,
-	}
-}
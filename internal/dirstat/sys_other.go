@@ -0,0 +1,22 @@
+//go:build !unix
+
+package dirstat
+
+import (
+	"io/fs"
+)
+
+// fileIdentity is not supported on this platform and always reports false.
+func fileIdentity(_ fs.FileInfo) (fileKey, uint64, bool) {
+	return fileKey{}, 0, false
+}
+
+// allocatedSize falls back to the apparent size on this platform.
+func allocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
+
+// fileOwner is not supported on this platform and always reports false.
+func fileOwner(_ fs.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,42 @@
+//go:build unix
+
+package dirstat
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the device/inode pair and hard link count of info.
+// The boolean is false if the platform-specific stat data is unavailable.
+func fileIdentity(info fs.FileInfo) (fileKey, uint64, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileKey{}, 0, false
+	}
+
+	//nolint:unconvert,gosec // Field types differ across unix platforms
+	return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, uint64(st.Nlink), true
+}
+
+// allocatedSize returns the number of bytes allocated on disk for info.
+// It falls back to the apparent size if the stat data is unavailable.
+func allocatedSize(info fs.FileInfo) int64 {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return info.Size()
+	}
+
+	return st.Blocks * 512 //nolint:mnd // st_blocks is always in 512-byte units
+}
+
+// fileOwner returns the user and group IDs owning info.
+// The boolean is false if the stat data is unavailable.
+func fileOwner(info fs.FileInfo) (uint32, uint32, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return st.Uid, st.Gid, true
+}
@@ -0,0 +1,19 @@
+//go:build linux || openbsd || dragonfly || solaris
+
+package dirstat
+
+import (
+	"io/fs"
+	"syscall"
+	"time"
+)
+
+// statTimes returns the access and status change times of info.
+func statTimes(info fs.FileInfo) (time.Time, time.Time, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return time.Unix(st.Atim.Unix()), time.Unix(st.Ctim.Unix()), true
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || openbsd || dragonfly || solaris || darwin || freebsd || netbsd)
+
+package dirstat
+
+import (
+	"io/fs"
+	"time"
+)
+
+// statTimes is not supported on this platform and always reports false.
+func statTimes(_ fs.FileInfo) (time.Time, time.Time, bool) {
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,79 @@
+package dirstat
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// topFiles keeps the N largest entries seen so far in a min-heap,
+// so memory stays bounded regardless of how many entries are offered.
+type topFiles struct {
+	limit     int
+	diskUsage bool
+	items     []FileStat
+}
+
+// newTopFiles creates a bounded collection holding at most limit entries.
+// The entries are allocated as they are offered, so unused collections stay small.
+func newTopFiles(limit int, diskUsage bool) *topFiles {
+	return &topFiles{
+		limit:     limit,
+		diskUsage: diskUsage,
+	}
+}
+
+// Len implements heap.Interface.
+func (t *topFiles) Len() int { return len(t.items) }
+
+// Less implements heap.Interface, ordering the smallest entry first.
+func (t *topFiles) Less(i, j int) bool {
+	return t.items[i].Bytes(t.diskUsage) < t.items[j].Bytes(t.diskUsage)
+}
+
+// Swap implements heap.Interface.
+func (t *topFiles) Swap(i, j int) { t.items[i], t.items[j] = t.items[j], t.items[i] }
+
+// Push implements heap.Interface.
+func (t *topFiles) Push(x any) {
+	t.items = append(t.items, x.(FileStat)) //nolint:forcetypeassert // Only FileStat values are pushed
+}
+
+// Pop implements heap.Interface.
+func (t *topFiles) Pop() any {
+	last := t.items[len(t.items)-1]
+	t.items = t.items[:len(t.items)-1]
+
+	return last
+}
+
+// offer adds file if it is among the largest entries seen so far.
+func (t *topFiles) offer(file FileStat) {
+	if t.limit <= 0 {
+		return
+	}
+
+	if len(t.items) < t.limit {
+		heap.Push(t, file)
+
+		return
+	}
+
+	if file.Bytes(t.diskUsage) <= t.items[0].Bytes(t.diskUsage) {
+		return
+	}
+
+	t.items[0] = file
+	heap.Fix(t, 0)
+}
+
+// sorted returns the collected entries ordered from smallest to largest.
+func (t *topFiles) sorted() []FileStat {
+	files := make([]FileStat, len(t.items))
+	copy(files, t.items)
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Bytes(t.diskUsage) < files[j].Bytes(t.diskUsage)
+	})
+
+	return files
+}
@@ -0,0 +1,124 @@
+package dirstat
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// treeTopFiles is the number of largest files listed individually per directory in Stats.Tree.
+const treeTopFiles = 10
+
+// TreeNode is a directory or file in the hierarchy of analyzed files.
+// The embedded statistics of a directory include all analyzed files below it.
+type TreeNode struct {
+	ExtStat
+
+	// Name is the base name, or the display path for the scan root.
+	Name string `json:"name"`
+	// Dir indicates whether the node is a directory.
+	Dir bool `json:"dir,omitempty"`
+	// Children lists the subdirectories and the largest files of a directory, largest first.
+	Children []*TreeNode `json:"children,omitempty"`
+	// Other holds the files of a directory that are too small to be listed in Children.
+	Other ExtStat `json:"other,omitzero"`
+}
+
+// treeDir holds the analyzed files directly inside a directory.
+type treeDir struct {
+	stat ExtStat
+	top  *topFiles
+}
+
+// addTree records a file in the directory at dir (slash path relative to the scan root).
+func (c *collector) addTree(dir string, file entry) {
+	s := c.shardFor(dir)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.tree[dir]
+	if !ok {
+		state = &treeDir{top: newTopFiles(treeTopFiles, c.diskUsage)}
+		s.tree[dir] = state
+	}
+
+	state.stat = state.stat.with(file)
+	state.top.offer(FileStat{Path: filepath.Base(file.path), Size: file.size, DiskSize: file.diskSize})
+}
+
+// buildTree assembles the directories collected in the shards into a hierarchy.
+// Each directory is recorded in a single shard, so no merging is needed.
+// The caller must hold the collector mutex.
+func (c *collector) buildTree() *TreeNode {
+	nodes := map[string]*TreeNode{"": {Dir: true}}
+
+	// node returns the directory at rel, creating it and its ancestors as needed
+	var node func(rel string) *TreeNode
+
+	node = func(rel string) *TreeNode {
+		if existing, ok := nodes[rel]; ok {
+			return existing
+		}
+
+		created := &TreeNode{Name: path.Base(rel), Dir: true}
+		nodes[rel] = created
+
+		parent := node(parentDir(rel))
+		parent.Children = append(parent.Children, created)
+
+		return created
+	}
+
+	for _, s := range c.shards {
+		s.mu.Lock()
+
+		for rel, state := range s.tree {
+			dir := node(rel)
+			dir.ExtStat = state.stat
+			dir.Other = state.stat
+
+			for _, file := range state.top.items {
+				dir.Children = append(dir.Children, &TreeNode{
+					Name:    file.Path,
+					ExtStat: ExtStat{Count: 1, Size: file.Size, DiskSize: file.DiskSize},
+				})
+
+				dir.Other.Count--
+				dir.Other.Size -= file.Size
+				dir.Other.DiskSize -= file.DiskSize
+			}
+		}
+
+		s.mu.Unlock()
+	}
+
+	root := nodes[""]
+	root.rollup(c.diskUsage)
+
+	return root
+}
+
+// rollup adds the statistics of all subdirectories to n and orders the children largest first.
+func (n *TreeNode) rollup(diskUsage bool) {
+	for _, child := range n.Children {
+		if !child.Dir {
+			continue
+		}
+
+		child.rollup(diskUsage)
+
+		n.Count += child.Count
+		n.Size += child.Size
+		n.DiskSize += child.DiskSize
+	}
+
+	sort.Slice(n.Children, func(i, j int) bool {
+		bytesI, bytesJ := n.Children[i].Bytes(diskUsage), n.Children[j].Bytes(diskUsage)
+		if bytesI != bytesJ {
+			return bytesI > bytesJ
+		}
+
+		return n.Children[i].Name < n.Children[j].Name
+	})
+}
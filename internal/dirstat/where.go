@@ -0,0 +1,604 @@
+package dirstat
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dustin/go-humanize"
+)
+
+// EntryTypeFile is the value of the "type" field of a filter expression for regular files.
+// Followed symlinks report EntryTypeSymlink.
+const EntryTypeFile = "file"
+
+// whereFieldKind is the value type of a filter expression field.
+type whereFieldKind int
+
+const (
+	whereString whereFieldKind = iota
+	whereSize
+	whereInt
+	whereTime
+)
+
+// whereFields lists the fields available in filter expressions.
+//
+//nolint:gochecknoglobals // Fixed lookup table
+var whereFields = map[string]whereFieldKind{
+	"path":  whereString,
+	"name":  whereString,
+	"ext":   whereString,
+	"type":  whereString,
+	"owner": whereString,
+	"group": whereString,
+	"size":  whereSize,
+	"depth": whereInt,
+	"mtime": whereTime,
+	"atime": whereTime,
+	"ctime": whereTime,
+}
+
+// whereEntry holds the properties of a file that filter expressions are evaluated against.
+type whereEntry struct {
+	// rel is the slash path relative to the scan root.
+	rel string
+	// ext is the file extension.
+	ext string
+	// kind is the entry type (file or symlink).
+	kind string
+	// depth is the depth below the scan root.
+	depth int
+	// info is the stat data of the file (or the symlink target).
+	info fs.FileInfo
+	// uid is the owning user ID, valid if hasOwner is set.
+	uid uint32
+	// gid is the owning group ID, valid if hasOwner is set.
+	gid uint32
+	// hasOwner indicates whether the owner is known.
+	hasOwner bool
+}
+
+// Where is a compiled filter expression.
+type Where struct {
+	source string
+	match  func(*whereEntry) bool
+	owners bool
+}
+
+// WhereError reports the column at which a filter expression could not be parsed.
+type WhereError struct {
+	// Expr is the expression being parsed.
+	Expr string
+	// Column is the 1-based column of the offending input.
+	Column int
+	// Message describes the problem.
+	Message string
+}
+
+// Error formats the error with a caret pointing at the failing column.
+func (e *WhereError) Error() string {
+	return fmt.Sprintf("column %d: %s\n  %s\n  %s^", e.Column, e.Message, e.Expr, strings.Repeat(" ", e.Column-1))
+}
+
+// String returns the source of the expression.
+func (w *Where) String() string {
+	return w.source
+}
+
+// usesOwners reports whether the expression compares the owner or group of files.
+func (w *Where) usesOwners() bool {
+	return w.owners
+}
+
+// matches reports whether file satisfies the expression.
+func (w *Where) matches(file *whereEntry) bool {
+	return w.match(file)
+}
+
+// ParseWhere compiles a filter expression such as
+//
+//	size > 50MB && (ext == ".log" || name =~ "^core\\.") && mtime < -7d
+//
+// Comparisons combine a field, an operator and a value, and can be joined with
+// "&&", "||", "!" and parentheses. String fields (path, name, ext, type, owner, group)
+// support ==, != and the regex operators =~ and !~. Numeric fields (size, depth) and
+// timestamps (mtime, atime, ctime) support ==, !=, <, <=, > and >=. Sizes accept units
+// such as 50MB, timestamps accept durations relative to now (e.g. -7d) or quoted
+// dates ("2024-01-31" or RFC 3339).
+func ParseWhere(expr string, now time.Time) (*Where, error) {
+	tokens, err := lexWhere(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &whereParser{expr: expr, tokens: tokens, now: now}
+
+	match, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := parser.peek(); tok.kind != tokenEOF {
+		return nil, parser.errorf(tok, "unexpected %s", tok)
+	}
+
+	return &Where{source: expr, match: match, owners: parser.owners}, nil
+}
+
+// tokenKind is the type of a lexical token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenLiteral
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+// token is a lexical token with its byte offset in the expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// String describes the token for error messages.
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+// whereOperators lists the operators, longest first.
+//
+//nolint:gochecknoglobals // Fixed lookup table
+var whereOperators = []string{"==", "!=", "<=", ">=", "=~", "!~", "&&", "||", "<", ">", "!"}
+
+// lexWhere splits expr into tokens.
+//
+//nolint:gocognit,cyclop // Straightforward character classification.
+func lexWhere(expr string) ([]token, error) {
+	var tokens []token
+
+	for pos := 0; pos < len(expr); {
+		char, width := utf8.DecodeRuneInString(expr[pos:])
+
+		switch {
+		case unicode.IsSpace(char):
+			pos += width
+		case char == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			pos++
+		case char == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			pos++
+		case char == '"':
+			end := pos + 1
+			for end < len(expr) && expr[end] != '"' {
+				if expr[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(expr) {
+				return nil, whereError(expr, pos, "unterminated string")
+			}
+
+			value, err := strconv.Unquote(expr[pos : end+1])
+			if err != nil {
+				return nil, whereError(expr, pos, "invalid string: "+err.Error())
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: pos})
+			pos = end + 1
+		case char == '_' || (char < utf8.RuneSelf && unicode.IsLetter(char)):
+			end := pos
+			for end < len(expr) && isIdentChar(expr[end]) {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[pos:end], pos: pos})
+			pos = end
+		case startsNumber(expr[pos:]) || ((char == '-' || char == '+' || char == '.') && startsNumber(expr[pos+1:])):
+			end := pos + 1
+			for end < len(expr) && (isIdentChar(expr[end]) || expr[end] == '.') {
+				end++
+			}
+
+			tokens = append(tokens, token{kind: tokenLiteral, text: expr[pos:end], pos: pos})
+			pos = end
+		default:
+			operator := ""
+
+			for _, candidate := range whereOperators {
+				if strings.HasPrefix(expr[pos:], candidate) {
+					operator = candidate
+
+					break
+				}
+			}
+
+			if operator == "" {
+				return nil, whereError(expr, pos, fmt.Sprintf("unexpected character %q", char))
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// isIdentChar reports whether char can be part of an identifier or literal.
+func isIdentChar(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+// startsNumber reports whether rest begins with a digit.
+func startsNumber(rest string) bool {
+	return rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
+
+// whereError creates a WhereError for the byte offset pos of expr.
+func whereError(expr string, pos int, message string) *WhereError {
+	return &WhereError{Expr: expr, Column: utf8.RuneCountInString(expr[:pos]) + 1, Message: message}
+}
+
+// whereParser is a recursive descent parser for filter expressions.
+type whereParser struct {
+	expr   string
+	tokens []token
+	pos    int
+	now    time.Time
+	users  map[uint32]string
+	groups map[uint32]string
+	owners bool
+}
+
+// peek returns the current token without consuming it.
+func (p *whereParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token.
+func (p *whereParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+// errorf creates a WhereError pointing at tok.
+func (p *whereParser) errorf(tok token, format string, args ...any) error {
+	return whereError(p.expr, tok.pos, fmt.Sprintf(format, args...))
+}
+
+// parseOr parses a disjunction of conjunctions.
+func (p *whereParser) parseOr() (func(*whereEntry) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && tok.text == "||"; tok = p.peek() {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		lhs := left
+		left = func(file *whereEntry) bool { return lhs(file) || right(file) }
+	}
+
+	return left, nil
+}
+
+// parseAnd parses a conjunction of unary expressions.
+func (p *whereParser) parseAnd() (func(*whereEntry) bool, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for tok := p.peek(); tok.kind == tokenOperator && tok.text == "&&"; tok = p.peek() {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		lhs := left
+		left = func(file *whereEntry) bool { return lhs(file) && right(file) }
+	}
+
+	return left, nil
+}
+
+// parseUnary parses an optionally negated primary expression.
+func (p *whereParser) parseUnary() (func(*whereEntry) bool, error) {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == "!" {
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return func(file *whereEntry) bool { return !operand(file) }, nil
+	}
+
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a parenthesized expression or a comparison.
+func (p *whereParser) parsePrimary() (func(*whereEntry) bool, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.errorf(closing, "expected \")\", found %s", closing)
+		}
+
+		return inner, nil
+	case tokenIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, p.errorf(tok, "expected field or \"(\", found %s", tok)
+	}
+}
+
+// parseComparison parses the operator and value following field.
+func (p *whereParser) parseComparison(field token) (func(*whereEntry) bool, error) {
+	kind, ok := whereFields[field.text]
+	if !ok {
+		return nil, p.errorf(field, "unknown field %q", field.text)
+	}
+
+	operator := p.next()
+	if operator.kind != tokenOperator || operator.text == "&&" || operator.text == "||" || operator.text == "!" {
+		return nil, p.errorf(operator, "expected comparison operator after %q, found %s", field.text, operator)
+	}
+
+	value := p.next()
+	if value.kind != tokenString && value.kind != tokenLiteral {
+		return nil, p.errorf(value, "expected value after %q, found %s", operator.text, value)
+	}
+
+	switch kind {
+	case whereString:
+		return p.compareString(field, operator, value)
+	case whereSize, whereInt:
+		return p.compareNumber(field, kind, operator, value)
+	default:
+		return p.compareTime(field, operator, value)
+	}
+}
+
+// compareString compiles a comparison of a string field.
+//
+//nolint:cyclop // One case per field and operator.
+func (p *whereParser) compareString(field, operator, value token) (func(*whereEntry) bool, error) {
+	var get func(*whereEntry) string
+
+	// Owners and groups can also be compared by numeric ID
+	_, err := strconv.ParseUint(value.text, 10, 32)
+	numericID := value.kind == tokenLiteral && err == nil && (field.text == "owner" || field.text == "group")
+
+	switch field.text {
+	case "path":
+		get = func(file *whereEntry) string { return file.rel }
+	case "name":
+		get = func(file *whereEntry) string { return path.Base(file.rel) }
+	case "ext":
+		get = func(file *whereEntry) string { return file.ext }
+	case "type":
+		get = func(file *whereEntry) string { return file.kind }
+	case "owner":
+		p.owners = true
+		get = p.ownerGetter(numericID, passwdFile, &p.users, func(file *whereEntry) uint32 { return file.uid })
+	case "group":
+		p.owners = true
+		get = p.ownerGetter(numericID, groupFile, &p.groups, func(file *whereEntry) uint32 { return file.gid })
+	}
+
+	if value.kind != tokenString && !numericID {
+		return nil, p.errorf(value, "expected quoted string for %q, found %s", field.text, value)
+	}
+
+	// Only files and symlinks are evaluated, any other type could never match
+	if field.text == "type" && (operator.text == "==" || operator.text == "!=") &&
+		value.text != EntryTypeFile && value.text != EntryTypeSymlink {
+		return nil, p.errorf(
+			value, "unsupported type %q, expected %q or %q", value.text, EntryTypeFile, EntryTypeSymlink,
+		)
+	}
+
+	switch operator.text {
+	case "==":
+		return func(file *whereEntry) bool { return get(file) == value.text }, nil
+	case "!=":
+		return func(file *whereEntry) bool { return get(file) != value.text }, nil
+	case "=~", "!~":
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, p.errorf(value, "invalid regex: %v", err)
+		}
+
+		negate := operator.text == "!~"
+
+		return func(file *whereEntry) bool { return re.MatchString(get(file)) != negate }, nil
+	default:
+		return nil, p.errorf(operator, "operator %q is not supported for %q", operator.text, field.text)
+	}
+}
+
+// ownerGetter returns a getter for the user or group of a file. Numeric values are
+// compared against the ID, names against the entry in file, which is loaded into names.
+func (p *whereParser) ownerGetter(
+	numericID bool,
+	file string,
+	names *map[uint32]string,
+	id func(*whereEntry) uint32,
+) func(*whereEntry) string {
+	if numericID {
+		return func(entry *whereEntry) string {
+			if !entry.hasOwner {
+				return ""
+			}
+
+			return strconv.FormatUint(uint64(id(entry)), 10)
+		}
+	}
+
+	if *names == nil {
+		*names = readIDNames(file)
+	}
+
+	lookup := *names
+
+	return func(entry *whereEntry) string {
+		if !entry.hasOwner {
+			return ""
+		}
+
+		return idName(lookup, id(entry))
+	}
+}
+
+// compareNumber compiles a comparison of size or depth.
+func (p *whereParser) compareNumber(field token, kind whereFieldKind, operator, value token) (func(*whereEntry) bool, error) {
+	if value.kind != tokenLiteral {
+		return nil, p.errorf(value, "expected number for %q, found %s", field.text, value)
+	}
+
+	var (
+		want int64
+		get  func(*whereEntry) int64
+	)
+
+	if kind == whereSize {
+		size, err := humanize.ParseBytes(value.text)
+		if err != nil {
+			return nil, p.errorf(value, "invalid size %q", value.text)
+		}
+
+		want = int64(size) //nolint:gosec // Size conversion from humanize is safe
+		get = func(file *whereEntry) int64 { return file.info.Size() }
+	} else {
+		depth, err := strconv.ParseInt(value.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(value, "invalid integer %q", value.text)
+		}
+
+		want = depth
+		get = func(file *whereEntry) int64 { return int64(file.depth) }
+	}
+
+	compare, err := p.ordering(field, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(file *whereEntry) bool {
+		got := get(file)
+
+		switch {
+		case got < want:
+			return compare(-1)
+		case got > want:
+			return compare(1)
+		default:
+			return compare(0)
+		}
+	}, nil
+}
+
+// compareTime compiles a comparison of a timestamp against a relative duration or a date.
+func (p *whereParser) compareTime(field, operator, value token) (func(*whereEntry) bool, error) {
+	var want time.Time
+
+	if value.kind == tokenLiteral {
+		offset, err := ParseDuration(value.text)
+		if err != nil {
+			return nil, p.errorf(value, "invalid duration %q", value.text)
+		}
+
+		want = p.now.Add(offset)
+	} else {
+		date, err := parseDate(value.text)
+		if err != nil {
+			return nil, p.errorf(value, "invalid date %q: expected YYYY-MM-DD or RFC 3339", value.text)
+		}
+
+		want = date
+	}
+
+	compare, err := p.ordering(field, operator)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(file *whereEntry) bool {
+		return compare(fileTime(file.info, field.text).Compare(want))
+	}, nil
+}
+
+// ordering returns a function evaluating operator on the result of a three-way comparison.
+func (p *whereParser) ordering(field, operator token) (func(int) bool, error) {
+	switch operator.text {
+	case "==":
+		return func(c int) bool { return c == 0 }, nil
+	case "!=":
+		return func(c int) bool { return c != 0 }, nil
+	case "<":
+		return func(c int) bool { return c < 0 }, nil
+	case "<=":
+		return func(c int) bool { return c <= 0 }, nil
+	case ">":
+		return func(c int) bool { return c > 0 }, nil
+	case ">=":
+		return func(c int) bool { return c >= 0 }, nil
+	default:
+		return nil, p.errorf(operator, "operator %q is not supported for %q", operator.text, field.text)
+	}
+}
+
+// parseDate parses a date (YYYY-MM-DD, local time) or an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing date: %w", err)
+	}
+
+	return date, nil
+}